@@ -2,32 +2,59 @@ package broker
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 
 	loafernatsx "github.com/silviolleite/loafer-natsx"
 
 	"github.com/silviolleite/loafer-natsx/consumer"
 
 	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+	"github.com/silviolleite/loafer-natsx/stream"
 )
 
-const defaultWorkers = 5
+const (
+	defaultWorkers         = 5
+	defaultShutdownTimeout = 30 * time.Second
+)
 
 // Broker represents a message broker that coordinates message routing and processing using NATS and configurable workers.
+// Each route is served by a single subscription whose messages are processed by a bounded pool of workers.
 type Broker struct {
-	log     logger.Logger
-	nc      *nats.Conn
-	metrics *brokerMetrics
-	workers int
+	jetStream       func() (jetstream.JetStream, error)
+	log             logger.Logger
+	tracer          consumer.Tracer
+	nc              *nats.Conn
+	metrics         Metrics
+	stats           *routeStats
+	routes          *routeTable
+	streams         []*stream.Definition
+	middlewares     []consumer.Middleware
+	workers         int
+	statsInterval   time.Duration
+	shutdownTimeout time.Duration
+	restartInitial  time.Duration
+	restartMax      time.Duration
+	restartAttempts int
+	failurePolicy   FailurePolicy
+	ensureStreams   bool
+	mu              sync.Mutex
 }
 
 // New creates a new Broker instance with the given NATS connection, logger, and optional configuration options.
 func New(nc *nats.Conn, log logger.Logger, opts ...Option) *Broker {
 	cfg := config{
-		workers: defaultWorkers,
+		workers:         defaultWorkers,
+		statsInterval:   defaultStatsInterval,
+		shutdownTimeout: defaultShutdownTimeout,
+		restartInitial:  defaultRestartInitial,
+		restartMax:      defaultRestartMax,
+		restartAttempts: defaultRestartAttempts,
 	}
 
 	for _, opt := range opts {
@@ -38,120 +65,187 @@ func New(nc *nats.Conn, log logger.Logger, opts ...Option) *Broker {
 		log = logger.NopLogger{}
 	}
 
+	if cfg.nc != nil {
+		nc = cfg.nc
+	}
+
 	return &Broker{
-		nc:      nc,
-		log:     log,
-		workers: cfg.workers,
-		metrics: cfg.metrics,
+		nc:              nc,
+		jetStream:       sharedJetStream(nc, cfg.js),
+		log:             log,
+		workers:         cfg.workers,
+		metrics:         cfg.metrics,
+		tracer:          cfg.tracer,
+		stats:           newRouteStats(cfg.metrics),
+		routes:          &routeTable{},
+		statsInterval:   cfg.statsInterval,
+		shutdownTimeout: cfg.shutdownTimeout,
+		restartInitial:  cfg.restartInitial,
+		restartMax:      cfg.restartMax,
+		restartAttempts: cfg.restartAttempts,
+		failurePolicy:   cfg.failurePolicy,
+		streams:         cfg.streams,
+		middlewares:     cfg.middlewares,
+		ensureStreams:   cfg.ensureStreams,
 	}
 }
 
 // Run starts routing and processing messages using the provided handlers and waits for completion or error detection.
+// A route that cannot be started is handled according to the failure policy (see WithFailurePolicy).
+// Once ctx is canceled or a route fails, every route stops receiving messages and Run waits up to the shutdown
+// timeout (see WithShutdownTimeout) for the messages already received to be handled and acknowledged. When the
+// timeout passes first, the returned error wraps loafernatsx.ErrShutdownTimeout and names the routes still in flight.
 func (b *Broker) Run(ctx context.Context, regs ...*RouteRegistration) error {
 	if len(regs) == 0 {
 		return loafernatsx.ErrNoRoutes
 	}
 
+	for _, reg := range regs {
+		if reg == nil {
+			return loafernatsx.ErrNilRouteRegistration
+		}
+	}
+
+	if b.ensureStreams {
+		if err := b.provisionStreams(ctx, regs); err != nil {
+			return err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	b.mu.Lock()
+
+	routes := b.routes.open(ctx, regs)
+
 	errCh := make(chan error, 1)
 
-	for _, reg := range regs {
-		if reg == nil {
-			return loafernatsx.ErrNilRouteRegistration
+	var restarts sync.WaitGroup
+
+	// AddRoute and the other route operations wait until every route started or failed.
+	b.startRoutes(ctx, routes, &restarts, func(err error) {
+		select {
+		case errCh <- err:
+		default:
 		}
+		cancel()
+	})
 
-		go func(r *RouteRegistration) {
-			if err := b.runRoute(ctx, r); err != nil {
-				select {
-				case errCh <- err:
-				default:
-				}
-				cancel()
-			}
-		}(reg)
+	// Polling starts once the routes started, so the first snapshot is taken right away.
+	if b.statsInterval > 0 {
+		go b.pollStats(ctx)
 	}
 
+	b.mu.Unlock()
+
+	var runErr error
+
 	select {
-	case err := <-errCh:
-		return err
+	case runErr = <-errCh:
 	case <-ctx.Done():
-		return nil
+		// A route failing during startup cancels ctx after reporting its error.
+		select {
+		case runErr = <-errCh:
+		default:
+		}
 	}
+
+	cancel()
+
+	b.mu.Lock()
+	b.routes.close()
+	consumers := b.routes.consumers()
+	b.mu.Unlock()
+
+	restarts.Wait()
+
+	return errors.Join(runErr, b.shutdown(consumers))
 }
 
+// runRoute starts a single subscription (or JetStream consumer) for the route.
+// Workers control how many messages of the route are processed in parallel,
+// unless the route defines its own concurrency.
 func (b *Broker) runRoute(
 	ctx context.Context,
+	cons *consumer.Consumer,
 	reg *RouteRegistration,
 ) error {
-	cons, err := consumer.New(b.nc, b.log)
-	if err != nil {
-		return err
+	var sErr error
+	if reg.BatchHandler() != nil {
+		sErr = cons.StartBatch(ctx, reg.Route(), b.instrumentBatch(reg))
+	} else {
+		sErr = cons.StartMessage(ctx, reg.Route(), b.instrument(reg.Route(), b.chain(reg)))
 	}
 
-	var (
-		wg      sync.WaitGroup
-		errOnce sync.Once
-		errCh   = make(chan error, 1)
-	)
+	if sErr != nil {
+		b.log.Error(
+			"route failed",
+			"subject", reg.Route().Subject(),
+			"error", sErr,
+		)
 
-	reportErr := func(e error) {
-		errOnce.Do(func() {
-			errCh <- e
-		})
+		return sErr
 	}
 
-	for i := 0; i < b.workers; i++ {
-		wg.Add(1)
+	return nil
+}
 
-		go func(workerID int) {
-			defer wg.Done()
+// provisionStreams ensures the configured stream definitions and the streams of all JetStream routes.
+func (b *Broker) provisionStreams(ctx context.Context, regs []*RouteRegistration) error {
+	js, err := b.jetStream()
+	if err != nil {
+		return err
+	}
+
+	if sErr := stream.Ensure(ctx, js, b.streams...); sErr != nil {
+		return sErr
+	}
 
-			defer func() {
-				if r := recover(); r != nil {
-					pErr := fmt.Errorf("worker panic: %v", r)
+	routes := make([]*router.Route, 0, len(regs))
+	for _, reg := range regs {
+		routes = append(routes, reg.Route())
+	}
 
-					b.log.Error(
-						"route worker panic recovered",
-						"subject", reg.Route().Subject(),
-						"worker", workerID,
-						"panic", r,
-					)
+	return stream.EnsureRoutes(ctx, js, routes...)
+}
 
-					reportErr(pErr)
-				}
-			}()
+// chain wraps the route handler with the broker-wide and route middlewares.
+func (b *Broker) chain(reg *RouteRegistration) consumer.MessageHandlerFunc {
+	mws := make([]consumer.Middleware, 0, len(b.middlewares)+len(reg.Middlewares()))
+	mws = append(mws, b.middlewares...)
+	mws = append(mws, reg.Middlewares()...)
 
-			wrapped := b.instrument(reg)
-			if sErr := cons.Start(ctx, reg.Route(), wrapped); sErr != nil {
-				b.log.Error(
-					"route worker failed",
-					"subject", reg.Route().Subject(),
-					"worker", workerID,
-					"error", sErr,
-				)
+	return consumer.Chain(reg.Handler(), mws...)
+}
 
-				reportErr(sErr)
-			}
-		}(i)
+// consumerOptions returns the options of the consumers serving the routes.
+func (b *Broker) consumerOptions() []consumer.Option {
+	opts := []consumer.Option{
+		consumer.WithConcurrency(b.workers),
+		consumer.WithTracer(b.tracer),
 	}
 
-	done := make(chan struct{})
-
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+	if b.metrics != nil {
+		opts = append(opts, consumer.WithObserver(metricsObserver{metrics: b.metrics}))
+	}
 
-	select {
-	case chErr := <-errCh:
-		return chErr
+	// When the shared context cannot be created, consumer.New fails with the same error.
+	if js, err := b.jetStream(); err == nil {
+		opts = append(opts, consumer.WithJetStream(js))
+	}
 
-	case <-done:
-		return nil
+	return opts
+}
 
-	case <-ctx.Done():
-		return nil
+// sharedJetStream returns a function returning js, or the JetStream context of nc
+// created on its first call, so every route of the broker shares the same context.
+func sharedJetStream(nc *nats.Conn, js jetstream.JetStream) func() (jetstream.JetStream, error) {
+	if js != nil {
+		return func() (jetstream.JetStream, error) { return js, nil }
 	}
+
+	return sync.OnceValues(func() (jetstream.JetStream, error) {
+		return jetstream.New(nc)
+	})
 }
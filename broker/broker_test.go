@@ -3,19 +3,25 @@ package broker_test
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	loafernatsx "github.com/silviolleite/loafer-natsx"
 	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/conn"
+	"github.com/silviolleite/loafer-natsx/consumer"
 	"github.com/silviolleite/loafer-natsx/logger"
 	"github.com/silviolleite/loafer-natsx/router"
+	"github.com/silviolleite/loafer-natsx/stream"
 )
 
 func newRoute(t *testing.T) *router.Route {
@@ -52,6 +58,60 @@ func TestRouteRegistrationValidation(t *testing.T) {
 	assert.ErrorIs(t, err, loafernatsx.ErrNilHandler)
 }
 
+func TestMessageRouteRegistrationValidation(t *testing.T) {
+	r := newRoute(t)
+
+	_, err := broker.NewMessageRouteRegistration(nil, func(context.Context, consumer.Message) (any, error) {
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, loafernatsx.ErrNilRoute)
+
+	_, err = broker.NewMessageRouteRegistration(r, nil)
+	assert.ErrorIs(t, err, loafernatsx.ErrNilHandler)
+
+	reg, err := broker.NewMessageRouteRegistration(r, func(context.Context, consumer.Message) (any, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, reg.Handler())
+}
+
+func TestBatchRouteRegistrationValidation(t *testing.T) {
+	h := func(context.Context, []consumer.Message) []error {
+		return nil
+	}
+
+	_, err := broker.NewBatchRouteRegistration(nil, h)
+	assert.ErrorIs(t, err, loafernatsx.ErrNilRoute)
+
+	_, err = broker.NewBatchRouteRegistration(newRoute(t), h)
+	assert.ErrorIs(t, err, loafernatsx.ErrBatchRequiresJetStream)
+
+	js, err := router.New(router.TypeJetStream, "batch.subject", router.WithStream("S"), router.WithDurable("d"))
+	assert.NoError(t, err)
+
+	_, err = broker.NewBatchRouteRegistration(js, nil)
+	assert.ErrorIs(t, err, loafernatsx.ErrNilHandler)
+
+	_, err = broker.NewBatchRouteRegistration(js, h)
+	assert.ErrorIs(t, err, loafernatsx.ErrInvalidBatchSize)
+
+	batch, err := router.New(
+		router.TypeJetStream,
+		"batch.subject",
+		router.WithStream("S"),
+		router.WithDurable("d"),
+		router.WithBatch(10, time.Second),
+	)
+	assert.NoError(t, err)
+
+	reg, err := broker.NewBatchRouteRegistration(batch, h)
+	assert.NoError(t, err)
+	assert.Equal(t, batch, reg.Route())
+	assert.NotNil(t, reg.BatchHandler())
+	assert.Nil(t, reg.Handler())
+}
+
 func TestRouteRegistration_Getters(t *testing.T) {
 	r := newRoute(t)
 
@@ -143,6 +203,43 @@ func TestBroker_Run_WorkersConcurrency(t *testing.T) {
 	assert.GreaterOrEqual(t, calls, 1)
 }
 
+func TestBroker_Run_PubSubDeliveredOncePerMessage(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	var calls atomic.Int32
+
+	b := broker.New(nc, logger.NopLogger{}, broker.WithWorkers(5))
+
+	reg, _ := broker.NewRouteRegistration(
+		newRoute(t),
+		func(ctx context.Context, _ []byte) (any, error) {
+			calls.Add(1)
+			return nil, nil
+		},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			_ = nc.Publish("test.subject", []byte("msg"))
+		}
+		_ = nc.Flush()
+		time.Sleep(150 * time.Millisecond)
+		cancel()
+	}()
+
+	err := b.Run(ctx, reg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
 func TestWithWorkersOption(t *testing.T) {
 	s, url := runServer()
 	defer s.Shutdown()
@@ -252,7 +349,7 @@ func TestBroker_PanicRecovery(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func counterValueBySubject(mfs []*dto.MetricFamily, metricName string, subject string) float64 {
+func counterValueByRoute(mfs []*dto.MetricFamily, metricName string, route string) float64 {
 	for _, mf := range mfs {
 		if mf.GetName() != metricName {
 			continue
@@ -262,7 +359,7 @@ func counterValueBySubject(mfs []*dto.MetricFamily, metricName string, subject s
 			lbls := m.GetLabel()
 
 			for _, l := range lbls {
-				if l.GetName() == "subject" && l.GetValue() == subject {
+				if l.GetName() == "route" && l.GetValue() == route {
 					if m.GetCounter() != nil {
 						return m.GetCounter().GetValue()
 					}
@@ -326,6 +423,321 @@ func TestWithMetricsOption(t *testing.T) {
 		if gErr != nil {
 			return false
 		}
-		return counterValueBySubject(mfs, "loafer_requests_total", subject) >= 1.0
+		return counterValueByRoute(mfs, "loafer_requests_total", subject) >= 1.0
 	}, 2*time.Second, 25*time.Millisecond)
 }
+
+func TestWithMetrics_HandlerTimeout(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	reg := prometheus.NewRegistry()
+
+	b := broker.New(nc, logger.NopLogger{}, broker.WithWorkers(1), broker.WithMetrics(reg))
+
+	subject := "metrics.timeout"
+	r, _ := router.New(router.TypePubSub, subject, router.WithHandlerTimeout(20*time.Millisecond))
+
+	registration, _ := broker.NewRouteRegistration(
+		r,
+		func(ctx context.Context, _ []byte) (any, error) {
+			<-ctx.Done()
+			return nil, nil
+		},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = nc.Publish(subject, []byte("msg"))
+	}()
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			mfs, gErr := reg.Gather()
+			return gErr == nil && counterValueByRoute(mfs, "loafer_timeouts_total", subject) >= 1.0
+		}, 2*time.Second, 10*time.Millisecond)
+		cancel()
+	}()
+
+	err := b.Run(ctx, registration)
+	assert.NoError(t, err)
+}
+
+func runJetStreamServer(t *testing.T) (*server.Server, string) {
+	t.Helper()
+
+	s, err := server.NewServer(&server.Options{
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go s.Start()
+
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatal("nats not ready")
+	}
+
+	return s, s.ClientURL()
+}
+
+func TestWithStreamProvisioning(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	audit, err := stream.New("AUDIT", stream.WithSubjects("audit.>"), stream.WithMaxAge(time.Hour))
+	assert.NoError(t, err)
+
+	b := broker.New(nc, logger.NopLogger{}, broker.WithStreamProvisioning(audit))
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("orders"),
+		router.WithEnableDLQ(),
+	)
+
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	assert.NoError(t, b.Run(ctx, reg))
+
+	js, _ := jetstream.New(nc)
+
+	for name, subject := range map[string]string{
+		"AUDIT":      "audit.>",
+		"ORDERS":     "orders.created",
+		"ORDERS_DLQ": "dlq.orders.created",
+	} {
+		str, sErr := js.Stream(context.Background(), name)
+		if assert.NoError(t, sErr, name) {
+			assert.Equal(t, []string{subject}, str.CachedInfo().Config.Subjects)
+		}
+	}
+}
+
+func TestWithStreamProvisioning_Error(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{}, broker.WithStreamProvisioning())
+
+	r, _ := router.New(router.TypeJetStream, "orders.created", router.WithStream("ORDERS"), router.WithDurable("orders"))
+
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		return nil, nil
+	})
+
+	err := b.Run(context.Background(), reg)
+	assert.ErrorContains(t, err, "ensure stream ORDERS")
+}
+
+func TestWithShutdownTimeout(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{}, broker.WithShutdownTimeout(100*time.Millisecond))
+
+	r, _ := router.New(router.TypePubSub, "shutdown.subject", router.WithName("shutdown"))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, reg) }()
+
+	require.Eventually(t, func() bool { return b.Health().Ready }, 2*time.Second, 10*time.Millisecond)
+
+	_ = nc.Publish("shutdown.subject", []byte("data"))
+	<-started
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, loafernatsx.ErrShutdownTimeout)
+		assert.Contains(t, err.Error(), "shutdown")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+}
+
+func TestRun_WaitsForInFlightHandlers(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{})
+
+	r, _ := router.New(router.TypePubSub, "inflight.subject")
+
+	started := make(chan struct{})
+
+	var handled atomic.Bool
+
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		handled.Store(true)
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, reg) }()
+
+	require.Eventually(t, func() bool { return b.Health().Ready }, 2*time.Second, 10*time.Millisecond)
+
+	_ = nc.Publish("inflight.subject", []byte("data"))
+	<-started
+
+	cancel()
+
+	assert.NoError(t, <-done)
+	assert.True(t, handled.Load())
+}
+
+// countingJetStream counts the consumers created and looked up through it.
+type countingJetStream struct {
+	jetstream.JetStream
+	created atomic.Int32
+	lookups atomic.Int32
+}
+
+func (c *countingJetStream) CreateOrUpdateConsumer(
+	ctx context.Context,
+	stream string,
+	cfg jetstream.ConsumerConfig,
+) (jetstream.Consumer, error) {
+	c.created.Add(1)
+	return c.JetStream.CreateOrUpdateConsumer(ctx, stream, cfg)
+}
+
+func (c *countingJetStream) Consumer(ctx context.Context, stream, name string) (jetstream.Consumer, error) {
+	c.lookups.Add(1)
+	return c.JetStream.Consumer(ctx, stream, name)
+}
+
+func TestWithJetStream(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	inner, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	js := &countingJetStream{JetStream: inner}
+
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithJetStream(js),
+		broker.WithStreamProvisioning(),
+		broker.WithStatsInterval(20*time.Millisecond),
+	)
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"shared.created",
+		router.WithStream("SHARED"),
+		router.WithDurable("shared"),
+	)
+
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+
+		assert.Eventually(t, func() bool {
+			return js.created.Load() == 1 && js.lookups.Load() > 0
+		}, 3*time.Second, 20*time.Millisecond)
+	}()
+
+	assert.NoError(t, b.Run(ctx, reg))
+}
+
+func TestWithConnection(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	c, err := conn.Open(url)
+	require.NoError(t, err)
+	defer func() { _ = c.Close(context.Background()) }()
+
+	b := broker.New(nil, logger.NopLogger{}, broker.WithConnection(c), broker.WithStreamProvisioning())
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"conn.created",
+		router.WithStream("CONN"),
+		router.WithDurable("conn"),
+	)
+
+	received := make(chan struct{}, 1)
+
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		received <- struct{}{}
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+
+		assert.Eventually(t, func() bool {
+			_, pErr := c.JetStream().Publish(context.Background(), "conn.created", []byte("data"))
+			return pErr == nil
+		}, 3*time.Second, 20*time.Millisecond)
+
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+			t.Error("message not received")
+		}
+	}()
+
+	assert.NoError(t, b.Run(ctx, reg))
+}
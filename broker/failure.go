@@ -0,0 +1,156 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	defaultRestartInitial = time.Second
+	defaultRestartMax     = 30 * time.Second
+
+	defaultRestartAttempts = 10
+
+	// restartBackoffFactor is the growth factor between consecutive restart delays.
+	restartBackoffFactor = 2
+)
+
+// FailurePolicy defines how Run reacts to a route that cannot be started.
+type FailurePolicy int
+
+const (
+	// FailFast stops every route and makes Run return the error. This is the default.
+	FailFast FailurePolicy = iota
+
+	// Isolate logs the error and marks the route as failed while the other routes keep running.
+	Isolate
+
+	// Restart retries to start the route with exponential backoff while the other routes
+	// keep running, which rides out transient errors such as a stream that does not exist
+	// yet during a rolling deploy. The route is marked as failed once the attempts set by
+	// WithRestartAttempts are exhausted, or right away when JetStream rejects its
+	// consumer.
+	Restart
+)
+
+// startRoutes starts the routes concurrently and applies the failure policy to the
+// routes that cannot be started. It returns once every route started or failed; the
+// routes being restarted are tracked by restarts. fail stops Run with an error.
+func (b *Broker) startRoutes(
+	ctx context.Context,
+	routes []*activeRoute,
+	restarts *sync.WaitGroup,
+	fail func(err error),
+) {
+	var started sync.WaitGroup
+
+	for _, r := range routes {
+		started.Go(func() {
+			err := b.startRoute(ctx, r)
+			if err == nil {
+				return
+			}
+
+			switch b.failurePolicy {
+			case FailFast:
+				b.routes.set(r, RouteFailed, err)
+				fail(err)
+			case Isolate:
+				b.routes.set(r, RouteFailed, err)
+			case Restart:
+				if permanent(err) {
+					b.giveUp(r, err)
+					return
+				}
+				b.routes.set(r, RouteRestarting, err)
+				restarts.Go(func() { b.restartRoute(ctx, r) })
+			}
+		})
+	}
+
+	started.Wait()
+}
+
+// restartRoute retries to start a route with exponential backoff until it starts,
+// ctx is done, the route is paused or removed, or the restart is given up.
+func (b *Broker) restartRoute(ctx context.Context, r *activeRoute) {
+	delay := b.restartInitial
+
+	for attempt := 1; attempt <= b.restartAttempts; attempt++ {
+		b.log.Info(
+			"route restart scheduled",
+			"subject", r.reg.Route().Subject(),
+			"attempt", attempt,
+			"delay", delay,
+		)
+
+		if !wait(ctx, delay) {
+			return
+		}
+
+		delay = min(delay*restartBackoffFactor, b.restartMax)
+
+		if b.retryStart(ctx, r, attempt == b.restartAttempts) {
+			return
+		}
+	}
+}
+
+// retryStart makes one attempt to start a restarting route. It reports whether
+// restarting should stop, because the route started, is no longer restarting, or
+// failed permanently or on its last attempt.
+func (b *Broker) retryStart(ctx context.Context, r *activeRoute, last bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.routes.restarting(r) {
+		return true
+	}
+
+	if err := b.startRoute(ctx, r); err != nil {
+		if last || permanent(err) {
+			b.giveUp(r, err)
+			return true
+		}
+
+		b.routes.set(r, RouteRestarting, err)
+		return false
+	}
+
+	b.log.Info("route restarted", "subject", r.reg.Route().Subject())
+
+	return true
+}
+
+// giveUp marks a route of the Restart failure policy as failed for good.
+func (b *Broker) giveUp(r *activeRoute, err error) {
+	b.routes.set(r, RouteFailed, err)
+	b.log.Error("route restart abandoned", "subject", r.reg.Route().Subject(), "error", err)
+}
+
+// permanent reports whether err cannot be fixed by retrying: JetStream answered and
+// rejected the consumer, for instance because its configuration is invalid or changes
+// a setting that cannot be updated. A missing stream is not permanent, as it may still
+// be created, and neither are errors without an answer, such as timeouts.
+func permanent(err error) bool {
+	var apiErr *jetstream.APIError
+
+	return errors.As(err, &apiErr) && !errors.Is(err, jetstream.ErrStreamNotFound)
+}
+
+// wait sleeps for d and reports whether ctx is still active.
+func wait(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,266 @@
+package broker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func missingStreamRoute(t *testing.T) *router.Route {
+	t.Helper()
+
+	r, err := router.New(
+		router.TypeJetStream,
+		"late.subject",
+		router.WithName("late"),
+		router.WithStream("LATE"),
+		router.WithDurable("late"),
+	)
+	require.NoError(t, err)
+
+	return r
+}
+
+func routeState(b *broker.Broker, label string) (broker.RouteState, string) {
+	for _, r := range b.Health().Routes {
+		if r.Route == label {
+			return r.State, r.Error
+		}
+	}
+	return -1, ""
+}
+
+func TestWithFailurePolicy_Isolate(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{}, broker.WithFailurePolicy(broker.Isolate))
+
+	okReg, received := countingRegistration(t, newRoute(t))
+	failReg, _ := countingRegistration(t, missingStreamRoute(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, okReg, failReg) }()
+
+	require.Eventually(t, func() bool {
+		state, _ := routeState(b, "late")
+		return state == broker.RouteFailed
+	}, 2*time.Second, 10*time.Millisecond)
+
+	state, reason := routeState(b, "late")
+	assert.Equal(t, broker.RouteFailed, state)
+	assert.NotEmpty(t, reason)
+
+	state, _ = routeState(b, "test.subject")
+	assert.Equal(t, broker.RouteRunning, state)
+
+	h := b.Health()
+	assert.True(t, h.Live, "an isolated failure must not fail liveness")
+	assert.False(t, h.Ready)
+
+	_ = nc.Publish("test.subject", []byte("still running"))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "still running", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("healthy route stopped after another route failed")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWithFailurePolicy_Restart(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithFailurePolicy(broker.Restart),
+		broker.WithRestartBackoff(20*time.Millisecond, 50*time.Millisecond),
+	)
+
+	reg, received := countingRegistration(t, missingStreamRoute(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, reg) }()
+
+	require.Eventually(t, func() bool {
+		state, reason := routeState(b, "late")
+		return state == broker.RouteRestarting && reason != ""
+	}, 2*time.Second, 10*time.Millisecond)
+
+	h := b.Health()
+	assert.True(t, h.Live)
+	assert.False(t, h.Ready)
+
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "LATE",
+		Subjects: []string{"late.subject"},
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return b.Health().Ready }, 2*time.Second, 10*time.Millisecond)
+
+	_, err = js.Publish(context.Background(), "late.subject", []byte("after restart"))
+	require.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "after restart", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("restarted route did not receive the message")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWithFailurePolicy_RestartStopsOnRemove(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithFailurePolicy(broker.Restart),
+		broker.WithRestartBackoff(10*time.Millisecond, 10*time.Millisecond),
+	)
+
+	okReg, _ := countingRegistration(t, newRoute(t))
+	failReg, _ := countingRegistration(t, missingStreamRoute(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, okReg, failReg) }()
+
+	require.Eventually(t, func() bool {
+		state, _ := routeState(b, "late")
+		return state == broker.RouteRestarting
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, b.RemoveRoute("late"))
+
+	require.Eventually(t, func() bool { return b.Health().Ready }, time.Second, 10*time.Millisecond)
+	assert.Len(t, b.Health().Routes, 1)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWithFailurePolicy_RestartGivesUp(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithFailurePolicy(broker.Restart),
+		broker.WithRestartBackoff(10*time.Millisecond, 10*time.Millisecond),
+		broker.WithRestartAttempts(2),
+	)
+
+	reg, _ := countingRegistration(t, missingStreamRoute(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, reg) }()
+
+	require.Eventually(t, func() bool {
+		state, _ := routeState(b, "late")
+		return state == broker.RouteFailed
+	}, 2*time.Second, 10*time.Millisecond)
+
+	h := b.Health()
+	assert.True(t, h.Live)
+	assert.False(t, h.Ready)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWithFailurePolicy_RestartPermanentError(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "LATE",
+		Subjects: []string{"late.subject"},
+	})
+	require.NoError(t, err)
+
+	_, err = js.CreateConsumer(context.Background(), "LATE", jetstream.ConsumerConfig{
+		Durable:       "late",
+		FilterSubject: "late.subject",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+	})
+	require.NoError(t, err)
+
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithFailurePolicy(broker.Restart),
+		broker.WithRestartBackoff(time.Minute, time.Minute),
+	)
+
+	// The durable exists with another deliver policy, which cannot be updated: no
+	// retry can fix it.
+	reg, _ := countingRegistration(t, missingStreamRoute(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, reg) }()
+
+	require.Eventually(t, func() bool {
+		state, reason := routeState(b, "late")
+		return state == broker.RouteFailed && reason != ""
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
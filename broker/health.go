@@ -0,0 +1,148 @@
+package broker
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nats-io/nats.go"
+)
+
+// RouteState describes the lifecycle state of a route served by Run.
+type RouteState int
+
+const (
+	// RouteStarting indicates that the route subscription is being created.
+	RouteStarting RouteState = iota
+
+	// RouteRunning indicates that the route is subscribed and handling messages.
+	RouteRunning
+
+	// RouteFailed indicates that the route could not be started.
+	RouteFailed
+
+	// RouteDraining indicates that Run is stopping and the route is draining.
+	RouteDraining
+
+	// RoutePaused indicates that the route was paused with PauseRoute.
+	RoutePaused
+
+	// RouteRestarting indicates that the route could not be started and is retried
+	// by the Restart failure policy.
+	RouteRestarting
+)
+
+// String returns the lowercase name of the state.
+func (s RouteState) String() string {
+	switch s {
+	case RouteStarting:
+		return "starting"
+	case RouteRunning:
+		return "running"
+	case RouteFailed:
+		return "failed"
+	case RouteDraining:
+		return "draining"
+	case RoutePaused:
+		return "paused"
+	case RouteRestarting:
+		return "restarting"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the state as its name.
+func (s RouteState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// RouteHealth is the state of a single route.
+type RouteHealth struct {
+	// Route is the route label: its name set with router.WithName, or its subject.
+	Route string `json:"route"`
+
+	// Error is the reason the route failed, if any.
+	Error string `json:"error,omitempty"`
+
+	// State is the route lifecycle state.
+	State RouteState `json:"state"`
+}
+
+// Health is a snapshot of the broker connection and routes.
+type Health struct {
+	// Connection is the NATS connection status, such as CONNECTED or RECONNECTING.
+	Connection string `json:"connection"`
+
+	// Routes holds the state of every route, in registration order.
+	Routes []RouteHealth `json:"routes"`
+
+	// Live reports whether the broker can still recover: the connection is not closed
+	// and, under the FailFast failure policy, no route failed. With the other policies
+	// failed routes only affect Ready.
+	Live bool `json:"live"`
+
+	// Ready reports whether the broker is handling messages: the connection is
+	// established and every route is running or paused.
+	Ready bool `json:"ready"`
+}
+
+// Health returns the current connection status and the state of every route of the
+// running (or last) Run call.
+func (b *Broker) Health() Health {
+	status := nats.DISCONNECTED
+	if b.nc != nil {
+		status = b.nc.Status()
+	}
+
+	h := Health{
+		Connection: status.String(),
+		Routes:     b.routes.health(),
+		Live:       status != nats.CLOSED,
+		Ready:      status == nats.CONNECTED,
+	}
+
+	if len(h.Routes) == 0 {
+		h.Ready = false
+	}
+
+	for _, r := range h.Routes {
+		if r.State == RouteFailed && b.failurePolicy == FailFast {
+			h.Live = false
+		}
+		if r.State != RouteRunning && r.State != RoutePaused {
+			h.Ready = false
+		}
+	}
+
+	return h
+}
+
+// LivenessHandler returns an http.Handler for liveness probes. It responds with the
+// Health snapshot as JSON and status 200 while the broker is live, or 503 once the
+// connection is closed or, under the FailFast failure policy, a route failed.
+func (b *Broker) LivenessHandler() http.Handler {
+	return healthHandler(b, func(h *Health) bool { return h.Live })
+}
+
+// ReadinessHandler returns an http.Handler for readiness probes. It responds with the
+// Health snapshot as JSON and status 200 while every route is running or paused on an
+// established connection, or 503 otherwise, such as while NATS is reconnecting.
+func (b *Broker) ReadinessHandler() http.Handler {
+	return healthHandler(b, func(h *Health) bool { return h.Ready })
+}
+
+func healthHandler(b *Broker, ok func(h *Health) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		h := b.Health()
+
+		code := http.StatusOK
+		if !ok(&h) {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+
+		_ = json.NewEncoder(w).Encode(h)
+	})
+}
@@ -0,0 +1,145 @@
+package broker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func probe(t *testing.T, h http.Handler) (int, broker.Health) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body struct {
+		Connection string `json:"connection"`
+		Routes     []struct {
+			Route string `json:"route"`
+			Error string `json:"error"`
+			State string `json:"state"`
+		} `json:"routes"`
+		Live  bool `json:"live"`
+		Ready bool `json:"ready"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	health := broker.Health{Connection: body.Connection, Live: body.Live, Ready: body.Ready}
+	for _, r := range body.Routes {
+		health.Routes = append(health.Routes, broker.RouteHealth{Route: r.Route, Error: r.Error})
+	}
+
+	return rec.Code, health
+}
+
+func TestHealth_RunningAndDisconnected(t *testing.T) {
+	s, url := runServer()
+
+	nc, err := nats.Connect(url, nats.MaxReconnects(-1), nats.ReconnectWait(10*time.Millisecond))
+	require.NoError(t, err)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{})
+
+	code, h := probe(t, b.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, h.Ready)
+	assert.Empty(t, h.Routes)
+
+	r, _ := router.New(router.TypePubSub, "health.subject", router.WithName("health"))
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, reg) }()
+
+	require.Eventually(t, func() bool { return b.Health().Ready }, 2*time.Second, 10*time.Millisecond)
+
+	code, h = probe(t, b.ReadinessHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "CONNECTED", h.Connection)
+	assert.Equal(t, []broker.RouteHealth{{Route: "health"}}, h.Routes)
+	assert.Equal(t, broker.RouteRunning, b.Health().Routes[0].State)
+
+	s.Shutdown()
+
+	require.Eventually(t, func() bool { return !b.Health().Ready }, 2*time.Second, 10*time.Millisecond)
+
+	code, _ = probe(t, b.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	code, _ = probe(t, b.LivenessHandler())
+	assert.Equal(t, http.StatusOK, code)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, broker.RouteDraining, b.Health().Routes[0].State)
+
+	nc.Close()
+
+	code, h = probe(t, b.LivenessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "CLOSED", h.Connection)
+}
+
+func TestHealth_RouteFailed(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{})
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"health.js",
+		router.WithStream("MISSING"),
+		router.WithDurable("health"),
+	)
+	reg, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		return nil, nil
+	})
+
+	require.Error(t, b.Run(context.Background(), reg))
+
+	h := b.Health()
+	require.Len(t, h.Routes, 1)
+	assert.Equal(t, broker.RouteFailed, h.Routes[0].State)
+	assert.NotEmpty(t, h.Routes[0].Error)
+	assert.False(t, h.Live)
+	assert.False(t, h.Ready)
+
+	code, _ := probe(t, b.LivenessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestRouteState_String(t *testing.T) {
+	for state, want := range map[broker.RouteState]string{
+		broker.RouteStarting:   "starting",
+		broker.RouteRunning:    "running",
+		broker.RouteFailed:     "failed",
+		broker.RouteDraining:   "draining",
+		broker.RoutePaused:     "paused",
+		broker.RouteRestarting: "restarting",
+		broker.RouteState(42):  "unknown",
+	} {
+		assert.Equal(t, want, state.String())
+	}
+}
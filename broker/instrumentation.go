@@ -4,36 +4,83 @@ import (
 	"context"
 	"time"
 
+	loafernatsx "github.com/silviolleite/loafer-natsx"
 	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/router"
 )
 
 func (b *Broker) instrument(
-	reg *RouteRegistration,
-) consumer.HandlerFunc {
-	handler := reg.Handler()
-	subject := reg.Route().Subject()
+	route *router.Route,
+	handler consumer.MessageHandlerFunc,
+) consumer.MessageHandlerFunc {
+	label := route.Label()
 
 	// No metrics configured → zero overhead wrapper
 	if b.metrics == nil {
 		return handler
 	}
 
-	return func(ctx context.Context, data []byte) (any, error) {
+	return func(ctx context.Context, msg consumer.Message) (any, error) {
 		start := time.Now()
 
-		b.metrics.inflightInc(subject)
-		defer b.metrics.inflightDec(subject)
+		b.metrics.InflightInc(label)
+		defer b.metrics.InflightDec(label)
+
+		res, err := handler(ctx, msg)
 
-		res, err := handler(ctx, data)
+		b.metrics.ObserveDuration(label, time.Since(start))
 
-		b.metrics.observeDuration(subject, time.Since(start))
+		// The consumer already reported a timeout for this execution, even if the handler returned late without error.
+		if consumer.IsHandlerTimeout(ctx) {
+			b.metrics.IncTimeout(label)
+			b.metrics.IncError(label)
+			return nil, loafernatsx.ErrHandlerTimeout
+		}
 
 		if err != nil {
-			b.metrics.incError(subject)
+			b.metrics.IncError(label)
 			return nil, err
 		}
 
-		b.metrics.incRequest(subject)
+		b.metrics.IncRequest(label)
 		return res, nil
 	}
 }
+
+func (b *Broker) instrumentBatch(
+	reg *RouteRegistration,
+) consumer.BatchHandlerFunc {
+	handler := reg.BatchHandler()
+	label := reg.Route().Label()
+
+	if b.metrics == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, msgs []consumer.Message) []error {
+		start := time.Now()
+
+		b.metrics.InflightInc(label)
+		defer b.metrics.InflightDec(label)
+
+		results := handler(ctx, msgs)
+
+		b.metrics.ObserveDuration(label, time.Since(start))
+
+		if consumer.IsHandlerTimeout(ctx) {
+			b.metrics.IncTimeout(label)
+			b.metrics.IncError(label)
+			return consumer.FailBatch(msgs, loafernatsx.ErrHandlerTimeout)
+		}
+
+		for i := range msgs {
+			if i < len(results) && results[i] != nil {
+				b.metrics.IncError(label)
+				continue
+			}
+			b.metrics.IncRequest(label)
+		}
+
+		return results
+	}
+}
@@ -6,43 +6,120 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-type brokerMetrics struct {
-	inflight      *prometheus.GaugeVec
-	requestsTotal *prometheus.CounterVec
-	errorsTotal   *prometheus.CounterVec
-	duration      *prometheus.HistogramVec
+// Metrics records the handler executions and delivery events of the broker routes.
+// Every method receives the route label: the route name set with router.WithName,
+// or the route subject. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// InflightInc records the start of a handler execution.
+	InflightInc(route string)
+
+	// InflightDec records the end of a handler execution.
+	InflightDec(route string)
+
+	// IncRequest counts a message handled successfully.
+	IncRequest(route string)
+
+	// IncError counts a message whose handler failed, including timeouts.
+	IncError(route string)
+
+	// IncTimeout counts a handler execution that exceeded the route handler timeout.
+	IncTimeout(route string)
+
+	// ObserveDuration records how long a handler execution took.
+	ObserveDuration(route string, d time.Duration)
+
+	// IncRedelivery counts a JetStream message delivered more than once.
+	IncRedelivery(route string)
+
+	// IncDLQPublish counts a failed message stored in the DLQ stream.
+	IncDLQPublish(route string)
+
+	// IncDLQFailure counts a failed message that could not be published to the DLQ.
+	IncDLQFailure(route string)
+
+	// IncAckError counts a failed ack, nak or term of a JetStream message.
+	IncAckError(route string)
+
+	// IncPanic counts a handler panic recovered by the consumer.
+	IncPanic(route string)
+
+	// SetConsumerStats records the latest JetStream consumer snapshot of a route.
+	SetConsumerStats(stats *RouteStats)
+
+	// DeleteConsumerStats drops the consumer snapshot of a route removed from the broker.
+	DeleteConsumerStats(route string)
 }
 
-func newMetrics(reg prometheus.Registerer) *brokerMetrics {
-	m := &brokerMetrics{
-		inflight: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "loafer_inflight",
-				Help: "Number of inflight handler executions",
-			},
-			[]string{"subject"},
-		),
-		requestsTotal: prometheus.NewCounterVec(
+// PrometheusMetrics implements Metrics with Prometheus collectors labeled by route.
+type PrometheusMetrics struct {
+	inflight          *prometheus.GaugeVec
+	requestsTotal     *prometheus.CounterVec
+	errorsTotal       *prometheus.CounterVec
+	timeoutsTotal     *prometheus.CounterVec
+	redeliveriesTotal *prometheus.CounterVec
+	dlqTotal          *prometheus.CounterVec
+	dlqFailuresTotal  *prometheus.CounterVec
+	ackErrorsTotal    *prometheus.CounterVec
+	panicsTotal       *prometheus.CounterVec
+	pending           *prometheus.GaugeVec
+	ackPending        *prometheus.GaugeVec
+	redelivered       *prometheus.GaugeVec
+	lastDelivered     *prometheus.GaugeVec
+	duration          *prometheus.HistogramVec
+}
+
+// NewPrometheusMetrics creates the broker Prometheus collectors and registers them with reg.
+// It panics if the collectors are already registered.
+func NewPrometheusMetrics(reg prometheus.Registerer, opts ...MetricsOption) *PrometheusMetrics {
+	cfg := newMetricsConfig(opts)
+
+	counter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "loafer_requests_total",
-				Help: "Total processed messages",
+				Namespace:   cfg.namespace,
+				Name:        name,
+				Help:        help,
+				ConstLabels: cfg.constLabels,
 			},
-			[]string{"subject"},
-		),
-		errorsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "loafer_errors_total",
-				Help: "Total handler errors",
+			[]string{"route"},
+		)
+	}
+
+	gauge := func(name, help string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   cfg.namespace,
+				Name:        name,
+				Help:        help,
+				ConstLabels: cfg.constLabels,
 			},
-			[]string{"subject"},
-		),
+			[]string{"route"},
+		)
+	}
+
+	m := &PrometheusMetrics{
+		inflight:          gauge("inflight", "Number of inflight handler executions"),
+		requestsTotal:     counter("requests_total", "Total processed messages"),
+		errorsTotal:       counter("errors_total", "Total handler errors"),
+		timeoutsTotal:     counter("timeouts_total", "Total handler executions that exceeded the route handler timeout"),
+		redeliveriesTotal: counter("redeliveries_total", "Total JetStream messages delivered more than once"),
+		dlqTotal:          counter("dlq_published_total", "Total failed messages published to the DLQ"),
+		dlqFailuresTotal:  counter("dlq_failures_total", "Total failed messages that could not be published to the DLQ"),
+		ackErrorsTotal:    counter("ack_errors_total", "Total failed acks, naks and terms of JetStream messages"),
+		panicsTotal:       counter("panics_total", "Total recovered handler panics"),
+		pending:           gauge("consumer_pending_messages", "Stream messages not yet delivered to the route consumer"),
+		ackPending:        gauge("consumer_ack_pending_messages", "Delivered messages waiting for an acknowledgement"),
+		redelivered:       gauge("consumer_redelivered_messages", "Unacknowledged messages delivered more than once"),
+		lastDelivered:     gauge("consumer_last_delivered_sequence", "Stream sequence of the last message delivered to the route consumer"),
 		duration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Name:    "loafer_request_duration_seconds",
-				Help:    "Handler duration",
-				Buckets: prometheus.DefBuckets,
+				Namespace:   cfg.namespace,
+				Name:        "request_duration_seconds",
+				Help:        "Handler duration",
+				ConstLabels: cfg.constLabels,
+				Buckets:     cfg.buckets,
 			},
-			[]string{"subject"},
+			[]string{"route"},
 		),
 	}
 
@@ -50,28 +127,90 @@ func newMetrics(reg prometheus.Registerer) *brokerMetrics {
 		m.inflight,
 		m.requestsTotal,
 		m.errorsTotal,
+		m.timeoutsTotal,
+		m.redeliveriesTotal,
+		m.dlqTotal,
+		m.dlqFailuresTotal,
+		m.ackErrorsTotal,
+		m.panicsTotal,
+		m.pending,
+		m.ackPending,
+		m.redelivered,
+		m.lastDelivered,
 		m.duration,
 	)
 
 	return m
 }
 
-func (m *brokerMetrics) inflightInc(subject string) {
-	m.inflight.WithLabelValues(subject).Inc()
+// InflightInc increments the inflight gauge.
+func (m *PrometheusMetrics) InflightInc(route string) {
+	m.inflight.WithLabelValues(route).Inc()
+}
+
+// InflightDec decrements the inflight gauge.
+func (m *PrometheusMetrics) InflightDec(route string) {
+	m.inflight.WithLabelValues(route).Dec()
+}
+
+// IncRequest increments the requests_total counter.
+func (m *PrometheusMetrics) IncRequest(route string) {
+	m.requestsTotal.WithLabelValues(route).Inc()
+}
+
+// IncError increments the errors_total counter.
+func (m *PrometheusMetrics) IncError(route string) {
+	m.errorsTotal.WithLabelValues(route).Inc()
+}
+
+// IncTimeout increments the timeouts_total counter.
+func (m *PrometheusMetrics) IncTimeout(route string) {
+	m.timeoutsTotal.WithLabelValues(route).Inc()
+}
+
+// ObserveDuration records d in the request_duration_seconds histogram.
+func (m *PrometheusMetrics) ObserveDuration(route string, d time.Duration) {
+	m.duration.WithLabelValues(route).Observe(d.Seconds())
+}
+
+// IncRedelivery increments the redeliveries_total counter.
+func (m *PrometheusMetrics) IncRedelivery(route string) {
+	m.redeliveriesTotal.WithLabelValues(route).Inc()
+}
+
+// IncDLQPublish increments the dlq_published_total counter.
+func (m *PrometheusMetrics) IncDLQPublish(route string) {
+	m.dlqTotal.WithLabelValues(route).Inc()
+}
+
+// IncDLQFailure increments the dlq_failures_total counter.
+func (m *PrometheusMetrics) IncDLQFailure(route string) {
+	m.dlqFailuresTotal.WithLabelValues(route).Inc()
 }
 
-func (m *brokerMetrics) inflightDec(subject string) {
-	m.inflight.WithLabelValues(subject).Dec()
+// IncAckError increments the ack_errors_total counter.
+func (m *PrometheusMetrics) IncAckError(route string) {
+	m.ackErrorsTotal.WithLabelValues(route).Inc()
 }
 
-func (m *brokerMetrics) incRequest(subject string) {
-	m.requestsTotal.WithLabelValues(subject).Inc()
+// IncPanic increments the panics_total counter.
+func (m *PrometheusMetrics) IncPanic(route string) {
+	m.panicsTotal.WithLabelValues(route).Inc()
 }
 
-func (m *brokerMetrics) incError(subject string) {
-	m.errorsTotal.WithLabelValues(subject).Inc()
+// SetConsumerStats sets the consumer_pending_messages, consumer_ack_pending_messages,
+// consumer_redelivered_messages and consumer_last_delivered_sequence gauges.
+func (m *PrometheusMetrics) SetConsumerStats(stats *RouteStats) {
+	m.pending.WithLabelValues(stats.Route).Set(float64(stats.NumPending))
+	m.ackPending.WithLabelValues(stats.Route).Set(float64(stats.NumAckPending))
+	m.redelivered.WithLabelValues(stats.Route).Set(float64(stats.NumRedelivered))
+	m.lastDelivered.WithLabelValues(stats.Route).Set(float64(stats.LastDelivered))
 }
 
-func (m *brokerMetrics) observeDuration(subject string, d time.Duration) {
-	m.duration.WithLabelValues(subject).Observe(d.Seconds())
+// DeleteConsumerStats deletes the consumer gauge series of route.
+func (m *PrometheusMetrics) DeleteConsumerStats(route string) {
+	m.pending.DeleteLabelValues(route)
+	m.ackPending.DeleteLabelValues(route)
+	m.redelivered.DeleteLabelValues(route)
+	m.lastDelivered.DeleteLabelValues(route)
 }
@@ -0,0 +1,61 @@
+package broker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultMetricsNamespace = "loafer"
+
+type metricsConfig struct {
+	constLabels map[string]string
+	namespace   string
+	buckets     []float64
+}
+
+// MetricsOption configures the metrics created by NewPrometheusMetrics, NewOTelMetrics and WithMetrics.
+type MetricsOption func(*metricsConfig)
+
+// WithMetricsNamespace sets the prefix of the metric names, "loafer" by default.
+// Prometheus names join it with an underscore (loafer_requests_total) and
+// OpenTelemetry names with a dot (loafer.requests).
+func WithMetricsNamespace(ns string) MetricsOption {
+	return func(c *metricsConfig) {
+		if ns != "" {
+			c.namespace = ns
+		}
+	}
+}
+
+// WithMetricsConstLabels adds labels with fixed values, such as the service name,
+// to every metric. OpenTelemetry metrics record them as attributes.
+func WithMetricsConstLabels(labels map[string]string) MetricsOption {
+	return func(c *metricsConfig) {
+		for k, v := range labels {
+			c.constLabels[k] = v
+		}
+	}
+}
+
+// WithMetricsBuckets sets the bucket boundaries, in seconds, of the handler duration
+// histogram. It defaults to prometheus.DefBuckets.
+func WithMetricsBuckets(buckets ...float64) MetricsOption {
+	return func(c *metricsConfig) {
+		if len(buckets) > 0 {
+			c.buckets = buckets
+		}
+	}
+}
+
+func newMetricsConfig(opts []MetricsOption) metricsConfig {
+	cfg := metricsConfig{
+		namespace:   defaultMetricsNamespace,
+		constLabels: map[string]string{},
+		buckets:     prometheus.DefBuckets,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
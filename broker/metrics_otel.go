@@ -0,0 +1,214 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/silviolleite/loafer-natsx/broker"
+
+// OTelMetrics implements Metrics with OpenTelemetry instruments carrying a route
+// attribute. It exposes the same measurements as PrometheusMetrics, so they can be
+// exported through an OTLP collector instead of a Prometheus scrape endpoint.
+type OTelMetrics struct {
+	inflight          metric.Int64UpDownCounter
+	requestsTotal     metric.Int64Counter
+	errorsTotal       metric.Int64Counter
+	timeoutsTotal     metric.Int64Counter
+	redeliveriesTotal metric.Int64Counter
+	dlqTotal          metric.Int64Counter
+	dlqFailuresTotal  metric.Int64Counter
+	ackErrorsTotal    metric.Int64Counter
+	panicsTotal       metric.Int64Counter
+	pending           metric.Int64ObservableGauge
+	ackPending        metric.Int64ObservableGauge
+	redelivered       metric.Int64ObservableGauge
+	lastDelivered     metric.Int64ObservableGauge
+	duration          metric.Float64Histogram
+	stats             map[string]RouteStats
+	attrs             []attribute.KeyValue
+	mu                sync.Mutex
+}
+
+// NewOTelMetrics creates the broker instruments from a meter of mp. It returns an
+// error if an instrument cannot be created.
+func NewOTelMetrics(mp metric.MeterProvider, opts ...MetricsOption) (*OTelMetrics, error) {
+	cfg := newMetricsConfig(opts)
+	meter := mp.Meter(meterName)
+	name := func(n string) string { return cfg.namespace + "." + n }
+
+	m := &OTelMetrics{stats: make(map[string]RouteStats)}
+	for k, v := range cfg.constLabels {
+		m.attrs = append(m.attrs, attribute.String(k, v))
+	}
+
+	var err error
+
+	m.inflight, err = meter.Int64UpDownCounter(
+		name("inflight"),
+		metric.WithDescription("Number of inflight handler executions"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := []struct {
+		dst         *metric.Int64Counter
+		name        string
+		description string
+	}{
+		{&m.requestsTotal, "requests", "Total processed messages"},
+		{&m.errorsTotal, "errors", "Total handler errors"},
+		{&m.timeoutsTotal, "timeouts", "Total handler executions that exceeded the route handler timeout"},
+		{&m.redeliveriesTotal, "redeliveries", "Total JetStream messages delivered more than once"},
+		{&m.dlqTotal, "dlq.published", "Total failed messages published to the DLQ"},
+		{&m.dlqFailuresTotal, "dlq.failures", "Total failed messages that could not be published to the DLQ"},
+		{&m.ackErrorsTotal, "ack.errors", "Total failed acks, naks and terms of JetStream messages"},
+		{&m.panicsTotal, "panics", "Total recovered handler panics"},
+	}
+
+	for _, c := range counters {
+		*c.dst, err = meter.Int64Counter(name(c.name), metric.WithDescription(c.description))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gauges := []struct {
+		dst         *metric.Int64ObservableGauge
+		name        string
+		description string
+	}{
+		{&m.pending, "consumer.pending", "Stream messages not yet delivered to the route consumer"},
+		{&m.ackPending, "consumer.ack_pending", "Delivered messages waiting for an acknowledgement"},
+		{&m.redelivered, "consumer.redelivered", "Unacknowledged messages delivered more than once"},
+		{&m.lastDelivered, "consumer.last_delivered", "Stream sequence of the last message delivered to the route consumer"},
+	}
+
+	for _, g := range gauges {
+		*g.dst, err = meter.Int64ObservableGauge(name(g.name), metric.WithDescription(g.description))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The consumer gauges are observed from the latest snapshots, so the series of a
+	// removed route disappear with its snapshot.
+	_, err = meter.RegisterCallback(m.observeConsumerStats, m.pending, m.ackPending, m.redelivered, m.lastDelivered)
+	if err != nil {
+		return nil, err
+	}
+
+	m.duration, err = meter.Float64Histogram(
+		name("request.duration"),
+		metric.WithDescription("Handler duration"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(cfg.buckets...),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// InflightInc increments the inflight up-down counter.
+func (m *OTelMetrics) InflightInc(route string) {
+	m.inflight.Add(context.Background(), 1, m.attributes(route))
+}
+
+// InflightDec decrements the inflight up-down counter.
+func (m *OTelMetrics) InflightDec(route string) {
+	m.inflight.Add(context.Background(), -1, m.attributes(route))
+}
+
+// IncRequest increments the requests counter.
+func (m *OTelMetrics) IncRequest(route string) {
+	m.requestsTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// IncError increments the errors counter.
+func (m *OTelMetrics) IncError(route string) {
+	m.errorsTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// IncTimeout increments the timeouts counter.
+func (m *OTelMetrics) IncTimeout(route string) {
+	m.timeoutsTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// ObserveDuration records d, in seconds, in the request.duration histogram.
+func (m *OTelMetrics) ObserveDuration(route string, d time.Duration) {
+	m.duration.Record(context.Background(), d.Seconds(), m.attributes(route))
+}
+
+// IncRedelivery increments the redeliveries counter.
+func (m *OTelMetrics) IncRedelivery(route string) {
+	m.redeliveriesTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// IncDLQPublish increments the dlq.published counter.
+func (m *OTelMetrics) IncDLQPublish(route string) {
+	m.dlqTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// IncDLQFailure increments the dlq.failures counter.
+func (m *OTelMetrics) IncDLQFailure(route string) {
+	m.dlqFailuresTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// IncAckError increments the ack.errors counter.
+func (m *OTelMetrics) IncAckError(route string) {
+	m.ackErrorsTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// IncPanic increments the panics counter.
+func (m *OTelMetrics) IncPanic(route string) {
+	m.panicsTotal.Add(context.Background(), 1, m.attributes(route))
+}
+
+// SetConsumerStats stores the snapshot reported by the consumer.pending,
+// consumer.ack_pending, consumer.redelivered and consumer.last_delivered gauges.
+func (m *OTelMetrics) SetConsumerStats(stats *RouteStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats[stats.Route] = *stats
+}
+
+// DeleteConsumerStats drops the snapshot of route, so its consumer gauges are no
+// longer reported.
+func (m *OTelMetrics) DeleteConsumerStats(route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.stats, route)
+}
+
+func (m *OTelMetrics) observeConsumerStats(_ context.Context, o metric.Observer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for route, stats := range m.stats {
+		attrs := m.attributes(route)
+
+		o.ObserveInt64(m.pending, int64(stats.NumPending), attrs)
+		o.ObserveInt64(m.ackPending, int64(stats.NumAckPending), attrs)
+		o.ObserveInt64(m.redelivered, int64(stats.NumRedelivered), attrs)
+		o.ObserveInt64(m.lastDelivered, int64(stats.LastDelivered), attrs)
+	}
+
+	return nil
+}
+
+func (m *OTelMetrics) attributes(route string) metric.MeasurementOption {
+	attrs := make([]attribute.KeyValue, 0, len(m.attrs)+1)
+	attrs = append(attrs, m.attrs...)
+	attrs = append(attrs, attribute.String("route", route))
+
+	return metric.WithAttributes(attrs...)
+}
@@ -1,13 +1,23 @@
-package broker
+package broker_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
 )
 
 func gatherMetrics(t *testing.T, reg *prometheus.Registry) []*dto.MetricFamily {
@@ -27,7 +37,7 @@ func findMetricFamily(t *testing.T, mfs []*dto.MetricFamily, name string) *dto.M
 	return nil
 }
 
-func getGaugeValue(t *testing.T, mfs []*dto.MetricFamily, name, subject string) float64 {
+func getGaugeValue(t *testing.T, mfs []*dto.MetricFamily, name, route string) float64 {
 	t.Helper()
 	mf := findMetricFamily(t, mfs, name)
 	if mf == nil {
@@ -35,7 +45,7 @@ func getGaugeValue(t *testing.T, mfs []*dto.MetricFamily, name, subject string)
 	}
 	for _, m := range mf.GetMetric() {
 		for _, l := range m.GetLabel() {
-			if l.GetName() == "subject" && l.GetValue() == subject {
+			if l.GetName() == "route" && l.GetValue() == route {
 				return m.GetGauge().GetValue()
 			}
 		}
@@ -43,7 +53,7 @@ func getGaugeValue(t *testing.T, mfs []*dto.MetricFamily, name, subject string)
 	return 0
 }
 
-func getCounterValue(t *testing.T, mfs []*dto.MetricFamily, name, subject string) float64 {
+func getCounterValue(t *testing.T, mfs []*dto.MetricFamily, name, route string) float64 {
 	t.Helper()
 	mf := findMetricFamily(t, mfs, name)
 	if mf == nil {
@@ -51,7 +61,7 @@ func getCounterValue(t *testing.T, mfs []*dto.MetricFamily, name, subject string
 	}
 	for _, m := range mf.GetMetric() {
 		for _, l := range m.GetLabel() {
-			if l.GetName() == "subject" && l.GetValue() == subject {
+			if l.GetName() == "route" && l.GetValue() == route {
 				return m.GetCounter().GetValue()
 			}
 		}
@@ -59,7 +69,7 @@ func getCounterValue(t *testing.T, mfs []*dto.MetricFamily, name, subject string
 	return 0
 }
 
-func getHistogramCount(t *testing.T, mfs []*dto.MetricFamily, name, subject string) uint64 {
+func getHistogramCount(t *testing.T, mfs []*dto.MetricFamily, name, route string) uint64 {
 	t.Helper()
 	mf := findMetricFamily(t, mfs, name)
 	if mf == nil {
@@ -67,7 +77,7 @@ func getHistogramCount(t *testing.T, mfs []*dto.MetricFamily, name, subject stri
 	}
 	for _, m := range mf.GetMetric() {
 		for _, l := range m.GetLabel() {
-			if l.GetName() == "subject" && l.GetValue() == subject {
+			if l.GetName() == "route" && l.GetValue() == route {
 				return m.GetHistogram().GetSampleCount()
 			}
 		}
@@ -78,36 +88,36 @@ func getHistogramCount(t *testing.T, mfs []*dto.MetricFamily, name, subject stri
 func TestNewMetrics_RegistersExpectedCollectors(t *testing.T) {
 	reg := prometheus.NewRegistry()
 
-	m := newMetrics(reg)
+	m := broker.NewPrometheusMetrics(reg)
 	assert.NotNil(t, m)
 }
 
 func TestInflightIncDec(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	m := newMetrics(reg)
+	m := broker.NewPrometheusMetrics(reg)
 
 	subject := "test.subject"
 
 	mfs := gatherMetrics(t, reg)
 	assert.Equal(t, float64(0), getGaugeValue(t, mfs, "loafer_inflight", subject))
 
-	m.inflightInc(subject)
+	m.InflightInc(subject)
 	mfs = gatherMetrics(t, reg)
 	assert.Equal(t, float64(1), getGaugeValue(t, mfs, "loafer_inflight", subject))
 
-	m.inflightDec(subject)
+	m.InflightDec(subject)
 	mfs = gatherMetrics(t, reg)
 	assert.Equal(t, float64(0), getGaugeValue(t, mfs, "loafer_inflight", subject))
 }
 
 func TestIncRequest_IncrementsCounterForSubject(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	m := newMetrics(reg)
+	m := broker.NewPrometheusMetrics(reg)
 
 	subject := "test.subject"
 
-	m.incRequest(subject)
-	m.incRequest(subject)
+	m.IncRequest(subject)
+	m.IncRequest(subject)
 
 	mfs := gatherMetrics(t, reg)
 	val := getCounterValue(t, mfs, "loafer_requests_total", subject)
@@ -116,11 +126,11 @@ func TestIncRequest_IncrementsCounterForSubject(t *testing.T) {
 
 func TestIncError_IncrementsCounterForSubject(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	m := newMetrics(reg)
+	m := broker.NewPrometheusMetrics(reg)
 
 	subject := "test.subject"
 
-	m.incError(subject)
+	m.IncError(subject)
 
 	mfs := gatherMetrics(t, reg)
 	val := getCounterValue(t, mfs, "loafer_errors_total", subject)
@@ -129,13 +139,217 @@ func TestIncError_IncrementsCounterForSubject(t *testing.T) {
 
 func TestObserveDuration_EmitsHistogramMetric(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	m := newMetrics(reg)
+	m := broker.NewPrometheusMetrics(reg)
 
 	subject := "test.subject"
 
-	m.observeDuration(subject, 150*time.Millisecond)
+	m.ObserveDuration(subject, 150*time.Millisecond)
 
 	mfs := gatherMetrics(t, reg)
 	count := getHistogramCount(t, mfs, "loafer_request_duration_seconds", subject)
 	assert.Equal(t, uint64(1), count)
 }
+
+func TestIncTimeout_IncrementsCounterForSubject(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := broker.NewPrometheusMetrics(reg)
+
+	subject := "test.subject"
+
+	m.IncTimeout(subject)
+
+	mfs := gatherMetrics(t, reg)
+	val := getCounterValue(t, mfs, "loafer_timeouts_total", subject)
+	assert.Equal(t, float64(1), val)
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	out := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			out[m.Name] = m.Data
+		}
+	}
+	return out
+}
+
+func sumByRoute(data metricdata.Aggregation, route string) int64 {
+	sum, ok := data.(metricdata.Sum[int64])
+	if !ok {
+		return 0
+	}
+
+	for _, dp := range sum.DataPoints {
+		if v, found := dp.Attributes.Value(attribute.Key("route")); found && v.AsString() == route {
+			return dp.Value
+		}
+	}
+	return 0
+}
+
+func TestOTelMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m, err := broker.NewOTelMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	m.InflightInc("orders")
+	m.InflightInc("orders")
+	m.InflightDec("orders")
+	m.IncRequest("orders")
+	m.IncError("orders")
+	m.IncTimeout("orders")
+	m.ObserveDuration("orders", 250*time.Millisecond)
+	m.SetConsumerStats(&broker.RouteStats{Route: "orders", NumPending: 5, LastDelivered: 42})
+
+	data := collect(t, reader)
+
+	assert.Equal(t, int64(1), sumByRoute(data["loafer.inflight"], "orders"))
+	assert.Equal(t, int64(1), sumByRoute(data["loafer.requests"], "orders"))
+	assert.Equal(t, int64(1), sumByRoute(data["loafer.errors"], "orders"))
+	assert.Equal(t, int64(1), sumByRoute(data["loafer.timeouts"], "orders"))
+
+	pending, ok := data["loafer.consumer.pending"].(metricdata.Gauge[int64])
+	require.True(t, ok)
+	require.Len(t, pending.DataPoints, 1)
+	assert.Equal(t, int64(5), pending.DataPoints[0].Value)
+
+	last, ok := data["loafer.consumer.last_delivered"].(metricdata.Gauge[int64])
+	require.True(t, ok)
+	require.Len(t, last.DataPoints, 1)
+	assert.Equal(t, int64(42), last.DataPoints[0].Value)
+
+	hist, ok := data["loafer.request.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, uint64(1), hist.DataPoints[0].Count)
+	assert.InDelta(t, 0.25, hist.DataPoints[0].Sum, 1e-9)
+
+	m.DeleteConsumerStats("orders")
+
+	assert.NotContains(t, collect(t, reader), "loafer.consumer.pending")
+}
+
+func TestWithMetricsBackend(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	reader := sdkmetric.NewManualReader()
+	m, err := broker.NewOTelMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	b := broker.New(nc, logger.NopLogger{}, broker.WithMetricsBackend(m))
+
+	subject := "metrics.otel"
+	r, _ := router.New(router.TypePubSub, subject)
+
+	registration, _ := broker.NewRouteRegistration(
+		r,
+		func(_ context.Context, data []byte) (any, error) {
+			if string(data) == "fail" {
+				return nil, errors.New("boom")
+			}
+			return nil, nil
+		},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			_ = nc.Publish(subject, []byte("ok"))
+			_ = nc.Publish(subject, []byte("fail"))
+
+			data := collect(t, reader)
+			return sumByRoute(data["loafer.requests"], subject) >= 1 &&
+				sumByRoute(data["loafer.errors"], subject) >= 1
+		}, 2*time.Second, 25*time.Millisecond)
+		cancel()
+	}()
+
+	assert.NoError(t, b.Run(ctx, registration))
+}
+
+func TestWithMetrics_OptionsAndDeliveryEvents(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	reg := prometheus.NewRegistry()
+
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithStreamProvisioning(),
+		broker.WithMetrics(
+			reg,
+			broker.WithMetricsNamespace("orders_svc"),
+			broker.WithMetricsConstLabels(map[string]string{"service": "orders"}),
+			broker.WithMetricsBuckets(0.1, 1),
+		),
+	)
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"orders.*",
+		router.WithName("orders"),
+		router.WithStream("ORDERS"),
+		router.WithDurable("orders"),
+		router.WithMaxDeliver(2),
+		router.WithEnableDLQ(),
+	)
+
+	registration, _ := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		return nil, errors.New("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			mfs, gErr := reg.Gather()
+			return gErr == nil && counterValueByRoute(mfs, "orders_svc_dlq_published_total", "orders") >= 1
+		}, 5*time.Second, 25*time.Millisecond)
+		cancel()
+	}()
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = nc.Publish("orders.created", []byte("msg"))
+	}()
+
+	require.NoError(t, b.Run(ctx, registration))
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	assert.InDelta(t, 1, counterValueByRoute(mfs, "orders_svc_redeliveries_total", "orders"), 0)
+	assert.InDelta(t, 2, counterValueByRoute(mfs, "orders_svc_errors_total", "orders"), 0)
+
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			assert.Equal(t, "orders", labels["service"], mf.GetName())
+		}
+
+		if mf.GetName() == "orders_svc_request_duration_seconds" {
+			assert.Len(t, mf.GetMetric()[0].GetHistogram().GetBucket(), 2)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package broker
+
+import (
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// metricsObserver reports consumer delivery events to the broker metrics.
+type metricsObserver struct {
+	metrics Metrics
+}
+
+var _ consumer.Observer = metricsObserver{}
+
+func (o metricsObserver) MessageRedelivered(route *router.Route, _ uint64) {
+	o.metrics.IncRedelivery(route.Label())
+}
+
+func (o metricsObserver) AckFailed(route *router.Route, _ error) {
+	o.metrics.IncAckError(route.Label())
+}
+
+func (o metricsObserver) DLQPublished(route *router.Route) {
+	o.metrics.IncDLQPublish(route.Label())
+}
+
+func (o metricsObserver) DLQPublishFailed(route *router.Route, _ error) {
+	o.metrics.IncDLQFailure(route.Label())
+}
+
+func (o metricsObserver) PanicRecovered(route *router.Route, _ any) {
+	o.metrics.IncPanic(route.Label())
+}
@@ -1,18 +1,40 @@
 package broker
 
 import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/silviolleite/loafer-natsx/conn"
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/stream"
 )
 
 type config struct {
-	metrics *brokerMetrics
-	workers int
+	nc              *nats.Conn
+	js              jetstream.JetStream
+	tracer          consumer.Tracer
+	metrics         Metrics
+	streams         []*stream.Definition
+	middlewares     []consumer.Middleware
+	workers         int
+	statsInterval   time.Duration
+	shutdownTimeout time.Duration
+	restartInitial  time.Duration
+	restartMax      time.Duration
+	restartAttempts int
+	failurePolicy   FailurePolicy
+	ensureStreams   bool
 }
 
 // Option is a function type used to modify the configuration of a component by applying changes to a config instance.
 type Option func(*config)
 
 // WithWorkers returns an Option to configure the number of workers in the config. It sets the value if n is greater than 0.
+// Workers are the number of messages each route processes in parallel; routes configured with
+// router.WithConcurrency override it. Use router.WithConcurrency(1) to keep a route strictly ordered.
 func WithWorkers(n int) Option {
 	return func(c *config) {
 		if n > 0 {
@@ -21,9 +43,127 @@ func WithWorkers(n int) Option {
 	}
 }
 
+// WithJetStream sets the JetStream context shared by the routes, stream provisioning
+// and consumer stats, such as the one of a conn.Connection. By default the broker
+// creates one from the connection given to New and shares it the same way.
+func WithJetStream(js jetstream.JetStream) Option {
+	return func(c *config) {
+		c.js = js
+	}
+}
+
+// WithConnection uses the NATS connection and the JetStream context of c instead of
+// the connection given to New, which can then be nil.
+func WithConnection(c *conn.Connection) Option {
+	return func(cfg *config) {
+		if c != nil {
+			cfg.nc = c.NATS()
+			cfg.js = c.JetStream()
+		}
+	}
+}
+
 // WithMetrics sets up Prometheus metrics using the provided Registerer and applies them to the configuration.
-func WithMetrics(reg prometheus.Registerer) Option {
+// It is equivalent to WithMetricsBackend(NewPrometheusMetrics(reg, opts...)).
+func WithMetrics(reg prometheus.Registerer, opts ...MetricsOption) Option {
+	return func(c *config) {
+		c.metrics = NewPrometheusMetrics(reg, opts...)
+	}
+}
+
+// WithMetricsBackend sets the Metrics implementation that records handler executions,
+// such as PrometheusMetrics or OTelMetrics. A nil backend disables metrics.
+func WithMetricsBackend(m Metrics) Option {
+	return func(c *config) {
+		c.metrics = m
+	}
+}
+
+// WithStreamProvisioning makes Run ensure JetStream streams before starting the routes.
+// The given definitions are ensured first; then, for every registered JetStream route,
+// the route stream is created or extended with the route subject and, when the DLQ is
+// enabled, a "<stream>_DLQ" stream is created for the DLQ subject unless another
+// stream already captures it. See stream.Ensure and stream.EnsureRoutes.
+func WithStreamProvisioning(defs ...*stream.Definition) Option {
+	return func(c *config) {
+		c.ensureStreams = true
+		c.streams = append(c.streams, defs...)
+	}
+}
+
+// WithMiddleware registers middlewares applied to the handlers of every route, in the
+// order given: the first middleware is the outermost one. For each message the chain
+// runs the broker metrics, then these middlewares, then the route middlewares
+// registered with WithRouteMiddleware, then the handler. Middlewares do not apply
+// to batch handlers.
+func WithMiddleware(mws ...consumer.Middleware) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, mws...)
+	}
+}
+
+// WithTracer sets a Tracer that starts a span for every message handled by the
+// routes. The span is the outermost step of the chain, so it covers the broker
+// metrics, the middlewares and the handler. It does not apply to batch handlers.
+func WithTracer(tracer consumer.Tracer) Option {
 	return func(c *config) {
-		c.metrics = newMetrics(reg)
+		c.tracer = tracer
+	}
+}
+
+// WithStatsInterval sets how often Run polls the JetStream consumer of every JetStream
+// route to refresh Stats and the consumer metrics. The default is 15 seconds; a zero
+// or negative interval disables polling.
+func WithStatsInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.statsInterval = d
+	}
+}
+
+// WithShutdownTimeout sets how long Run waits, once its context is canceled or a route
+// fails, for the routes to finish handling and acknowledging the messages they already
+// received. Handlers see their context canceled only once the timeout passes. The
+// default is 30 seconds; a zero or negative timeout returns without waiting and cancels
+// the context of the handlers still running.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.shutdownTimeout = d
+	}
+}
+
+// WithFailurePolicy sets how Run reacts to a route that cannot be started: FailFast (the
+// default) stops every route and returns the error, Isolate marks the route as failed
+// and keeps the others running, and Restart retries to start it with the backoff set
+// by WithRestartBackoff. Unknown policies are ignored.
+func WithFailurePolicy(p FailurePolicy) Option {
+	return func(c *config) {
+		if p >= FailFast && p <= Restart {
+			c.failurePolicy = p
+		}
+	}
+}
+
+// WithRestartBackoff sets the delays between the attempts of the Restart failure policy:
+// the first attempt waits initial, and each further one doubles the delay up to maxDelay.
+// The defaults are 1 and 30 seconds. Non-positive values are ignored.
+func WithRestartBackoff(initial, maxDelay time.Duration) Option {
+	return func(c *config) {
+		if initial > 0 {
+			c.restartInitial = initial
+		}
+		if maxDelay > 0 {
+			c.restartMax = maxDelay
+		}
+	}
+}
+
+// WithRestartAttempts sets how many times the Restart failure policy retries to start a
+// route before giving up and marking it as failed. The default is 10. Non-positive
+// values are ignored.
+func WithRestartAttempts(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.restartAttempts = n
+		}
 	}
 }
@@ -9,14 +9,39 @@ import (
 // RouteRegistration binds a router.Route with its corresponding handler.
 // It is validated at creation time to prevent invalid broker configuration.
 type RouteRegistration struct {
-	route   *router.Route
-	handler consumer.HandlerFunc
+	route       *router.Route
+	handler     consumer.MessageHandlerFunc
+	batch       consumer.BatchHandlerFunc
+	middlewares []consumer.Middleware
 }
 
-// NewRouteRegistration creates a validated RouteRegistration.
+// RegistrationOption configures a RouteRegistration during creation.
+type RegistrationOption func(*RouteRegistration)
+
+// WithRouteMiddleware registers middlewares that apply only to this route. They run
+// inside the broker-wide middlewares registered with WithMiddleware, in the order
+// given. Route middlewares do not apply to batch handlers.
+func WithRouteMiddleware(mws ...consumer.Middleware) RegistrationOption {
+	return func(rr *RouteRegistration) {
+		rr.middlewares = append(rr.middlewares, mws...)
+	}
+}
+
+// NewRouteRegistration creates a validated RouteRegistration for a byte-only handler.
 func NewRouteRegistration(
 	r *router.Route,
 	h consumer.HandlerFunc,
+	opts ...RegistrationOption,
+) (*RouteRegistration, error) {
+	return NewMessageRouteRegistration(r, consumer.AdaptHandler(h), opts...)
+}
+
+// NewMessageRouteRegistration creates a validated RouteRegistration for a message-aware handler,
+// which receives headers, the concrete subject and JetStream metadata alongside the payload.
+func NewMessageRouteRegistration(
+	r *router.Route,
+	h consumer.MessageHandlerFunc,
+	opts ...RegistrationOption,
 ) (*RouteRegistration, error) {
 	if r == nil {
 		return nil, loafernatsx.ErrNilRoute
@@ -26,9 +51,43 @@ func NewRouteRegistration(
 		return nil, loafernatsx.ErrNilHandler
 	}
 
-	return &RouteRegistration{
+	rr := &RouteRegistration{
 		route:   r,
 		handler: h,
+	}
+
+	for _, opt := range opts {
+		opt(rr)
+	}
+
+	return rr, nil
+}
+
+// NewBatchRouteRegistration creates a validated RouteRegistration for a batch handler.
+// The route must be a JetStream route configured with router.WithBatch.
+func NewBatchRouteRegistration(
+	r *router.Route,
+	h consumer.BatchHandlerFunc,
+) (*RouteRegistration, error) {
+	if r == nil {
+		return nil, loafernatsx.ErrNilRoute
+	}
+
+	if h == nil {
+		return nil, loafernatsx.ErrNilHandler
+	}
+
+	if r.Type() != router.TypeJetStream {
+		return nil, loafernatsx.ErrBatchRequiresJetStream
+	}
+
+	if r.BatchSize() <= 0 {
+		return nil, loafernatsx.ErrInvalidBatchSize
+	}
+
+	return &RouteRegistration{
+		route: r,
+		batch: h,
 	}, nil
 }
 
@@ -37,7 +96,17 @@ func (rr *RouteRegistration) Route() *router.Route {
 	return rr.route
 }
 
-// Handler returns the associated handler.
-func (rr *RouteRegistration) Handler() consumer.HandlerFunc {
+// Handler returns the associated handler. It is nil for batch registrations.
+func (rr *RouteRegistration) Handler() consumer.MessageHandlerFunc {
 	return rr.handler
 }
+
+// Middlewares returns the middlewares registered for this route.
+func (rr *RouteRegistration) Middlewares() []consumer.Middleware {
+	return rr.middlewares
+}
+
+// BatchHandler returns the associated batch handler, or nil for single-message registrations.
+func (rr *RouteRegistration) BatchHandler() consumer.BatchHandlerFunc {
+	return rr.batch
+}
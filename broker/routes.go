@@ -0,0 +1,424 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// activeRoute is a route served by the running broker.
+type activeRoute struct {
+	reg    *RouteRegistration
+	cons   *consumer.Consumer
+	cancel context.CancelFunc
+	err    error
+	state  RouteState
+}
+
+// routeTable holds the routes of the current (or last) Run, in registration order.
+// Its context is the Run context; it is nil while the broker is not running.
+type routeTable struct {
+	ctx    context.Context
+	routes []*activeRoute
+	mu     sync.RWMutex
+}
+
+// open starts a new Run with the given routes in RouteStarting.
+func (t *routeTable) open(ctx context.Context, regs []*RouteRegistration) []*activeRoute {
+	routes := make([]*activeRoute, 0, len(regs))
+	for _, reg := range regs {
+		routes = append(routes, &activeRoute{reg: reg, state: RouteStarting})
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ctx = ctx
+	t.routes = routes
+
+	return slices.Clone(routes)
+}
+
+// close ends the Run, moving every route that did not fail to RouteDraining.
+func (t *routeTable) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ctx = nil
+
+	for _, r := range t.routes {
+		if r.state != RouteFailed {
+			r.state = RouteDraining
+		}
+	}
+}
+
+// running returns the Run context, or ErrBrokerNotRunning.
+func (t *routeTable) running() (context.Context, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.ctx == nil {
+		return nil, loafernatsx.ErrBrokerNotRunning
+	}
+
+	return t.ctx, nil
+}
+
+// add appends a route in RouteStarting unless another route has the same label.
+func (t *routeTable) add(reg *RouteRegistration) (*activeRoute, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	label := reg.Route().Label()
+	if t.find(label) != nil {
+		return nil, fmt.Errorf("%w: %s", loafernatsx.ErrDuplicateRoute, label)
+	}
+
+	r := &activeRoute{reg: reg, state: RouteStarting}
+	t.routes = append(t.routes, r)
+
+	return r, nil
+}
+
+// remove drops a route from the table.
+func (t *routeTable) remove(r *activeRoute) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.routes = slices.DeleteFunc(t.routes, func(a *activeRoute) bool { return a == r })
+}
+
+// get returns the route with the given label while the broker is running. Routes
+// given to Run may share a label; such a label does not identify a single route.
+func (t *routeTable) get(label string) (*activeRoute, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.ctx == nil {
+		return nil, loafernatsx.ErrBrokerNotRunning
+	}
+
+	r := t.find(label)
+	if r == nil {
+		return nil, fmt.Errorf("%w: %s", loafernatsx.ErrRouteNotFound, label)
+	}
+
+	if slices.ContainsFunc(t.routes, func(a *activeRoute) bool { return a != r && a.reg.Route().Label() == label }) {
+		return nil, fmt.Errorf("%w: %s", loafernatsx.ErrDuplicateRoute, label)
+	}
+
+	return r, nil
+}
+
+func (t *routeTable) find(label string) *activeRoute {
+	for _, r := range t.routes {
+		if r.reg.Route().Label() == label {
+			return r
+		}
+	}
+	return nil
+}
+
+// set updates the state of a route. Draining routes keep their state, so a
+// subscription started while Run stops is not reported as running.
+func (t *routeTable) set(r *activeRoute, state RouteState, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r.state == RouteDraining {
+		return
+	}
+
+	r.state = state
+	r.err = err
+}
+
+// serving records the consumer serving a started route.
+func (t *routeTable) serving(r *activeRoute, cons *consumer.Consumer, cancel context.CancelFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r.cons = cons
+	r.cancel = cancel
+}
+
+// stateOf returns the state of a route.
+func (t *routeTable) stateOf(r *activeRoute) RouteState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return r.state
+}
+
+// restarting reports whether a route of the running broker is waiting to be restarted.
+func (t *routeTable) restarting(r *activeRoute) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.ctx != nil && r.state == RouteRestarting && slices.Contains(t.routes, r)
+}
+
+// consumers returns the consumers serving the routes, with their route label.
+func (t *routeTable) consumers() map[*consumer.Consumer]string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make(map[*consumer.Consumer]string, len(t.routes))
+	for _, r := range t.routes {
+		if r.cons != nil {
+			out[r.cons] = r.reg.Route().Label()
+		}
+	}
+	return out
+}
+
+// detach returns the consumer serving a route and forgets it.
+func (t *routeTable) detach(r *activeRoute) (*consumer.Consumer, context.CancelFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cons, cancel := r.cons, r.cancel
+	r.cons, r.cancel = nil, nil
+
+	return cons, cancel
+}
+
+func (t *routeTable) health() []RouteHealth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make([]RouteHealth, 0, len(t.routes))
+	for _, r := range t.routes {
+		rh := RouteHealth{Route: r.reg.Route().Label(), State: r.state}
+		if r.err != nil {
+			rh.Error = r.err.Error()
+		}
+		out = append(out, rh)
+	}
+
+	return out
+}
+
+// jetStream returns the JetStream routes that are not paused.
+func (t *routeTable) jetStream() []*router.Route {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var routes []*router.Route
+	for _, r := range t.routes {
+		if r.reg.Route().Type() == router.TypeJetStream && r.state != RoutePaused {
+			routes = append(routes, r.reg.Route())
+		}
+	}
+	return routes
+}
+
+// has reports whether route is registered.
+func (t *routeTable) has(route *router.Route) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return slices.ContainsFunc(t.routes, func(r *activeRoute) bool { return r.reg.Route() == route })
+}
+
+// AddRoute starts serving a new route on the running broker. The route label (its
+// name set with router.WithName, or its subject) must not be used by another route.
+// It returns ErrBrokerNotRunning outside Run, and the subscription error, without
+// adding the route, when the route cannot be started. Streams are not provisioned
+// for added routes.
+func (b *Broker) AddRoute(reg *RouteRegistration) error {
+	if reg == nil {
+		return loafernatsx.ErrNilRouteRegistration
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ctx, err := b.routes.running()
+	if err != nil {
+		return err
+	}
+
+	r, err := b.routes.add(reg)
+	if err != nil {
+		return err
+	}
+
+	if sErr := b.startRoute(ctx, r); sErr != nil {
+		b.routes.remove(r)
+		return sErr
+	}
+
+	return nil
+}
+
+// RemoveRoute stops serving the route with the given label and removes it from the
+// broker. Like Run, it waits up to the shutdown timeout for the messages already
+// received by the route to be handled; the route is removed even when the wait
+// times out. Routes given to Run may share a label, such as two unnamed routes on
+// the same subject: the route operations return ErrDuplicateRoute for such a label,
+// so name the routes with router.WithName to manage them.
+func (b *Broker) RemoveRoute(label string) error {
+	b.mu.Lock()
+
+	r, err := b.routes.get(label)
+	if err != nil {
+		b.mu.Unlock()
+		return err
+	}
+
+	wait := b.stopRoute(r)
+
+	b.routes.remove(r)
+	b.stats.remove(r.reg.Route())
+
+	b.mu.Unlock()
+
+	return wait()
+}
+
+// PauseRoute stops the subscription of the route with the given label while keeping
+// it registered, waiting for its in-flight messages like RemoveRoute. JetStream
+// routes keep their durable consumer, so messages published while paused are
+// delivered after ResumeRoute. Pausing a paused route does nothing.
+func (b *Broker) PauseRoute(label string) error {
+	b.mu.Lock()
+
+	r, err := b.routes.get(label)
+	if err != nil {
+		b.mu.Unlock()
+		return err
+	}
+
+	if b.routes.stateOf(r) == RoutePaused {
+		b.mu.Unlock()
+		return nil
+	}
+
+	wait := b.stopRoute(r)
+	b.routes.set(r, RoutePaused, nil)
+
+	b.mu.Unlock()
+
+	return wait()
+}
+
+// ResumeRoute subscribes again the paused route with the given label. Resuming a
+// route that is not paused does nothing.
+func (b *Broker) ResumeRoute(label string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ctx, err := b.routes.running()
+	if err != nil {
+		return err
+	}
+
+	r, err := b.routes.get(label)
+	if err != nil {
+		return err
+	}
+
+	if b.routes.stateOf(r) != RoutePaused {
+		return nil
+	}
+
+	b.routes.set(r, RouteStarting, nil)
+
+	if sErr := b.startRoute(ctx, r); sErr != nil {
+		b.routes.set(r, RoutePaused, nil)
+		return sErr
+	}
+
+	return nil
+}
+
+// startRoute subscribes a route with its own consumer, so it can be stopped on its own.
+// On error the route state is left to the caller.
+func (b *Broker) startRoute(ctx context.Context, r *activeRoute) error {
+	cons, err := consumer.New(b.nc, b.log, b.consumerOptions()...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	if sErr := b.runRoute(ctx, cons, r.reg); sErr != nil {
+		cancel()
+		return sErr
+	}
+
+	b.routes.serving(r, cons, cancel)
+	b.routes.set(r, RouteRunning, nil)
+
+	return nil
+}
+
+// stopRoute cancels the subscription of a route and returns a function waiting up to
+// the shutdown timeout for its in-flight messages. It is called with b.mu held, while
+// the returned function is called without it, so the wait does not block the other
+// route operations.
+func (b *Broker) stopRoute(r *activeRoute) func() error {
+	cons, cancel := b.routes.detach(r)
+	if cons == nil {
+		return func() error { return nil }
+	}
+
+	cancel()
+
+	return func() error {
+		return b.shutdown(map[*consumer.Consumer]string{cons: r.reg.Route().Label()})
+	}
+}
+
+// shutdown waits up to the shutdown timeout for the consumers of stopped routes, with
+// their route label, to finish their in-flight messages.
+func (b *Broker) shutdown(consumers map[*consumer.Consumer]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), max(b.shutdownTimeout, 0))
+	defer cancel()
+
+	if b.shutdownTimeout <= 0 {
+		// Nothing waits: the expired wait only cancels the handlers still running.
+		for cons := range consumers {
+			_ = cons.Wait(ctx)
+		}
+		return nil
+	}
+
+	var (
+		mu      sync.Mutex
+		pending []string
+		wg      sync.WaitGroup
+	)
+
+	for cons, label := range consumers {
+		wg.Go(func() {
+			if cons.Wait(ctx) != nil {
+				mu.Lock()
+				pending = append(pending, label)
+				mu.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	slices.Sort(pending)
+	pending = slices.Compact(pending)
+
+	err := fmt.Errorf("%w: %s", loafernatsx.ErrShutdownTimeout, strings.Join(pending, ", "))
+	b.log.Error("shutdown timeout", "timeout", b.shutdownTimeout, "error", err)
+
+	return err
+}
@@ -0,0 +1,274 @@
+package broker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// runBroker runs b with regs until the test ends and waits for every route to run.
+func runBroker(t *testing.T, b *broker.Broker, regs ...*broker.RouteRegistration) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, regs...) }()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	require.Eventually(t, func() bool { return b.Health().Ready }, 2*time.Second, 10*time.Millisecond)
+}
+
+func countingRegistration(t *testing.T, r *router.Route) (*broker.RouteRegistration, chan string) {
+	t.Helper()
+
+	received := make(chan string, 10)
+
+	reg, err := broker.NewRouteRegistration(r, func(_ context.Context, data []byte) (any, error) {
+		received <- string(data)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	return reg, received
+}
+
+func TestRouteOperations_NotRunning(t *testing.T) {
+	b := broker.New(nil, logger.NopLogger{})
+
+	reg, _ := countingRegistration(t, newRoute(t))
+
+	assert.ErrorIs(t, b.AddRoute(reg), loafernatsx.ErrBrokerNotRunning)
+	assert.ErrorIs(t, b.AddRoute(nil), loafernatsx.ErrNilRouteRegistration)
+	assert.ErrorIs(t, b.RemoveRoute("test.subject"), loafernatsx.ErrBrokerNotRunning)
+	assert.ErrorIs(t, b.PauseRoute("test.subject"), loafernatsx.ErrBrokerNotRunning)
+	assert.ErrorIs(t, b.ResumeRoute("test.subject"), loafernatsx.ErrBrokerNotRunning)
+}
+
+func TestRun_SharedLabel(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{})
+
+	reg1, received1 := countingRegistration(t, newRoute(t))
+	reg2, received2 := countingRegistration(t, newRoute(t))
+	runBroker(t, b, reg1, reg2)
+
+	_ = nc.Publish("test.subject", []byte("data"))
+
+	for _, received := range []chan string{received1, received2} {
+		select {
+		case msg := <-received:
+			assert.Equal(t, "data", msg)
+		case <-time.After(2 * time.Second):
+			t.Fatal("route sharing a subject did not receive the message")
+		}
+	}
+
+	assert.ErrorIs(t, b.PauseRoute("test.subject"), loafernatsx.ErrDuplicateRoute)
+	assert.ErrorIs(t, b.RemoveRoute("test.subject"), loafernatsx.ErrDuplicateRoute)
+	assert.Len(t, b.Health().Routes, 2)
+}
+
+func TestAddAndRemoveRoute(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{})
+
+	base, _ := countingRegistration(t, newRoute(t))
+	runBroker(t, b, base)
+
+	r, _ := router.New(router.TypePubSub, "tenant.acme", router.WithName("acme"))
+	reg, received := countingRegistration(t, r)
+
+	require.NoError(t, b.AddRoute(reg))
+	assert.ErrorIs(t, b.AddRoute(reg), loafernatsx.ErrDuplicateRoute)
+
+	h := b.Health()
+	require.Len(t, h.Routes, 2)
+	assert.Equal(t, broker.RouteHealth{Route: "acme", State: broker.RouteRunning}, h.Routes[1])
+
+	_ = nc.Publish("tenant.acme", []byte("one"))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "one", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("added route did not receive the message")
+	}
+
+	require.NoError(t, b.RemoveRoute("acme"))
+	assert.ErrorIs(t, b.RemoveRoute("acme"), loafernatsx.ErrRouteNotFound)
+	assert.Len(t, b.Health().Routes, 1)
+
+	_ = nc.Publish("tenant.acme", []byte("two"))
+	_ = nc.Flush()
+
+	select {
+	case msg := <-received:
+		t.Fatalf("removed route received %q", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRemoveRoute_WaitDoesNotBlockRouteOperations(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	r, _ := router.New(router.TypePubSub, "tenant.slow", router.WithName("slow"))
+	slow, err := broker.NewRouteRegistration(r, func(context.Context, []byte) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	runBroker(t, b, slow)
+
+	_ = nc.Publish("tenant.slow", []byte("data"))
+	<-started
+
+	removed := make(chan error, 1)
+	go func() { removed <- b.RemoveRoute("slow") }()
+
+	require.Eventually(t, func() bool { return len(b.Health().Routes) == 0 }, time.Second, 10*time.Millisecond)
+
+	other, _ := router.New(router.TypePubSub, "tenant.other", router.WithName("other"))
+	reg, _ := countingRegistration(t, other)
+
+	added := make(chan error, 1)
+	go func() { added <- b.AddRoute(reg) }()
+
+	select {
+	case err = <-added:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("AddRoute blocked while RemoveRoute waited for an in-flight message")
+	}
+
+	close(release)
+
+	select {
+	case err = <-removed:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RemoveRoute did not return after the in-flight message was handled")
+	}
+}
+
+func TestAddRoute_StartError(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	b := broker.New(nc, logger.NopLogger{})
+
+	base, _ := countingRegistration(t, newRoute(t))
+	runBroker(t, b, base)
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"missing.subject",
+		router.WithStream("MISSING"),
+		router.WithDurable("missing"),
+	)
+	reg, _ := countingRegistration(t, r)
+
+	assert.Error(t, b.AddRoute(reg))
+
+	h := b.Health()
+	assert.Len(t, h.Routes, 1)
+	assert.True(t, h.Ready)
+}
+
+func TestPauseAndResumeRoute(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "PAUSE",
+		Subjects: []string{"pause.>"},
+	})
+	require.NoError(t, err)
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"pause.>",
+		router.WithName("pause"),
+		router.WithStream("PAUSE"),
+		router.WithDurable("pause"),
+	)
+	reg, received := countingRegistration(t, r)
+
+	b := broker.New(nc, logger.NopLogger{})
+	runBroker(t, b, reg)
+
+	require.NoError(t, b.PauseRoute("pause"))
+	require.NoError(t, b.PauseRoute("pause"))
+
+	h := b.Health()
+	assert.Equal(t, broker.RoutePaused, h.Routes[0].State)
+	assert.True(t, h.Ready)
+
+	_, err = js.Publish(context.Background(), "pause.one", []byte("while paused"))
+	require.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		t.Fatalf("paused route received %q", msg)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	require.NoError(t, b.ResumeRoute("pause"))
+	require.NoError(t, b.ResumeRoute("pause"))
+	assert.Equal(t, broker.RouteRunning, b.Health().Routes[0].State)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "while paused", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("resumed route did not receive the message published while paused")
+	}
+
+	assert.ErrorIs(t, b.PauseRoute("unknown"), loafernatsx.ErrRouteNotFound)
+	assert.ErrorIs(t, b.ResumeRoute("unknown"), loafernatsx.ErrRouteNotFound)
+}
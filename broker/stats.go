@@ -0,0 +1,180 @@
+package broker
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+const defaultStatsInterval = 15 * time.Second
+
+// RouteStats is a snapshot of the JetStream consumer of a route.
+type RouteStats struct {
+	// UpdatedAt is when the snapshot was taken.
+	UpdatedAt time.Time
+
+	// Route is the route label: its name set with router.WithName, or its subject.
+	Route string
+
+	// Stream is the name of the stream the route consumes.
+	Stream string
+
+	// Durable is the name of the durable consumer.
+	Durable string
+
+	// NumPending is the number of stream messages not yet delivered to the consumer.
+	NumPending uint64
+
+	// LastDelivered is the stream sequence of the last message delivered to the consumer.
+	LastDelivered uint64
+
+	// NumAckPending is the number of delivered messages waiting for an acknowledgement.
+	NumAckPending int
+
+	// NumRedelivered is the number of messages delivered more than once and not yet acknowledged.
+	NumRedelivered int
+}
+
+// routeStats holds the latest snapshot of every JetStream route and mirrors it to
+// the consumer gauges of metrics, when set.
+type routeStats struct {
+	metrics Metrics
+	byRoute map[*router.Route]RouteStats
+	mu      sync.RWMutex
+}
+
+func newRouteStats(metrics Metrics) *routeStats {
+	return &routeStats{metrics: metrics, byRoute: make(map[*router.Route]RouteStats)}
+}
+
+// set stores the snapshot of route while registered reports it is still part of the
+// broker, so a poll racing with RemoveRoute does not bring its gauges back.
+func (s *routeStats) set(route *router.Route, stats *RouteStats, registered func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !registered() {
+		return
+	}
+
+	s.byRoute[route] = *stats
+
+	if s.metrics != nil {
+		s.metrics.SetConsumerStats(stats)
+	}
+}
+
+// remove drops the snapshot and the consumer gauge series of route.
+func (s *routeStats) remove(route *router.Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.byRoute, route)
+
+	if s.metrics != nil && route.Type() == router.TypeJetStream {
+		s.metrics.DeleteConsumerStats(route.Label())
+	}
+}
+
+func (s *routeStats) all() []RouteStats {
+	s.mu.RLock()
+	out := make([]RouteStats, 0, len(s.byRoute))
+	for _, st := range s.byRoute {
+		out = append(out, st)
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(out, func(a, b RouteStats) int {
+		return cmp.Or(cmp.Compare(a.Stream, b.Stream), cmp.Compare(a.Durable, b.Durable))
+	})
+
+	return out
+}
+
+// Stats returns the latest consumer snapshot of every JetStream route, sorted by
+// stream and durable name. Snapshots are refreshed every stats interval while Run
+// is running; routes not polled yet are omitted.
+func (b *Broker) Stats() []RouteStats {
+	return b.stats.all()
+}
+
+// pollStats refreshes the consumer snapshot of the JetStream routes that are not
+// paused right away and then every stats interval until ctx is done.
+func (b *Broker) pollStats(ctx context.Context) {
+	js, err := b.jetStream()
+	if err != nil {
+		b.log.Error("consumer stats disabled", "error", err)
+		return
+	}
+
+	ticker := time.NewTicker(b.statsInterval)
+	defer ticker.Stop()
+
+	consumers := make(map[*router.Route]jetstream.Consumer)
+
+	for {
+		routes := b.routes.jetStream()
+
+		for _, route := range routes {
+			b.collectStats(ctx, js, consumers, route)
+		}
+
+		// Forget the consumers of the routes removed or paused since the last poll.
+		for route := range consumers {
+			if !slices.Contains(routes, route) {
+				delete(consumers, route)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Broker) collectStats(
+	ctx context.Context,
+	js jetstream.JetStream,
+	consumers map[*router.Route]jetstream.Consumer,
+	route *router.Route,
+) {
+	ctx, cancel := context.WithTimeout(ctx, b.statsInterval)
+	defer cancel()
+
+	cons, ok := consumers[route]
+	if !ok {
+		var err error
+		if cons, err = js.Consumer(ctx, route.Stream(), route.Durable()); err != nil {
+			b.log.Debug("consumer stats error", "subject", route.Subject(), "error", err)
+			return
+		}
+		consumers[route] = cons
+	}
+
+	info, err := cons.Info(ctx)
+	if err != nil {
+		b.log.Debug("consumer stats error", "subject", route.Subject(), "error", err)
+		return
+	}
+
+	stats := RouteStats{
+		UpdatedAt:      time.Now(),
+		Route:          route.Label(),
+		Stream:         route.Stream(),
+		Durable:        route.Durable(),
+		NumPending:     info.NumPending,
+		LastDelivered:  info.Delivered.Stream,
+		NumAckPending:  info.NumAckPending,
+		NumRedelivered: info.NumRedelivered,
+	}
+
+	b.stats.set(route, &stats, func() bool { return b.routes.has(route) })
+}
@@ -0,0 +1,188 @@
+package broker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func gaugeValue(t *testing.T, reg *prometheus.Registry, name, route string) float64 {
+	t.Helper()
+
+	v, _ := lookupGauge(t, reg, name, route)
+	return v
+}
+
+func lookupGauge(t *testing.T, reg *prometheus.Registry, name, route string) (float64, bool) {
+	t.Helper()
+
+	mfs, err := reg.Gather()
+	if !assert.NoError(t, err) {
+		return 0, false
+	}
+
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "route" && l.GetValue() == route {
+					return m.GetGauge().GetValue(), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+func TestStats_JetStreamRoutes(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "STATS",
+		Subjects: []string{"stats.>"},
+	})
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err = js.Publish(context.Background(), "stats.created", []byte("msg"))
+		require.NoError(t, err)
+	}
+
+	reg := prometheus.NewRegistry()
+
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithMetrics(reg),
+		broker.WithStatsInterval(50*time.Millisecond),
+	)
+	assert.Empty(t, b.Stats())
+
+	jsRoute, _ := router.New(
+		router.TypeJetStream,
+		"stats.>",
+		router.WithName("stats"),
+		router.WithStream("STATS"),
+		router.WithDurable("stats"),
+	)
+	coreRoute, _ := router.New(router.TypePubSub, "core.stats")
+
+	release := make(chan struct{})
+	handler := func(ctx context.Context, _ []byte) (any, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil, nil
+	}
+
+	jsReg, _ := broker.NewRouteRegistration(jsRoute, handler)
+	coreReg, _ := broker.NewRouteRegistration(coreRoute, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+
+		assert.Eventually(t, func() bool {
+			stats := b.Stats()
+			return len(stats) == 1 && stats[0].NumAckPending == 3
+		}, 3*time.Second, 20*time.Millisecond)
+
+		stats := b.Stats()[0]
+		assert.Equal(t, "stats", stats.Route)
+		assert.Equal(t, "STATS", stats.Stream)
+		assert.Equal(t, "stats", stats.Durable)
+		assert.Equal(t, uint64(0), stats.NumPending)
+		assert.Equal(t, uint64(3), stats.LastDelivered)
+		assert.False(t, stats.UpdatedAt.IsZero())
+
+		assert.Eventually(t, func() bool {
+			return gaugeValue(t, reg, "loafer_consumer_ack_pending_messages", "stats") == 3 &&
+				gaugeValue(t, reg, "loafer_consumer_last_delivered_sequence", "stats") == 3
+		}, 3*time.Second, 20*time.Millisecond)
+
+		close(release)
+	}()
+
+	assert.NoError(t, b.Run(ctx, jsReg, coreReg))
+}
+
+func TestStats_PollsOnStartAndDropsRemovedRoutes(t *testing.T) {
+	s, url := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "STATS",
+		Subjects: []string{"stats.>"},
+	})
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+
+	// The interval is far longer than the test, so only the first poll can fill the stats.
+	b := broker.New(
+		nc,
+		logger.NopLogger{},
+		broker.WithMetrics(reg),
+		broker.WithStatsInterval(time.Hour),
+	)
+
+	jsRoute, _ := router.New(
+		router.TypeJetStream,
+		"stats.>",
+		router.WithName("stats"),
+		router.WithStream("STATS"),
+		router.WithDurable("stats"),
+	)
+	jsReg, _ := countingRegistration(t, jsRoute)
+	coreReg, _ := countingRegistration(t, newRoute(t))
+
+	runBroker(t, b, jsReg, coreReg)
+
+	require.Eventually(t, func() bool {
+		_, ok := lookupGauge(t, reg, "loafer_consumer_pending_messages", "stats")
+		return len(b.Stats()) == 1 && ok
+	}, 3*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, b.RemoveRoute("stats"))
+
+	assert.Empty(t, b.Stats())
+	for _, name := range []string{
+		"loafer_consumer_pending_messages",
+		"loafer_consumer_ack_pending_messages",
+		"loafer_consumer_redelivered_messages",
+		"loafer_consumer_last_delivered_sequence",
+	} {
+		_, ok := lookupGauge(t, reg, name, "stats")
+		assert.False(t, ok, name)
+	}
+}
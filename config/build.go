@@ -0,0 +1,267 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/conn"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+var routeTypes = map[string]router.Type{
+	"pubsub":        router.TypePubSub,
+	"queue":         router.TypeQueue,
+	"request_reply": router.TypeRequestReply,
+	"jetstream":     router.TypeJetStream,
+}
+
+var deliveryPolicies = map[string]router.DeliverPolicy{
+	"all":              router.DeliverAllPolicy,
+	"last":             router.DeliverLastPolicy,
+	"new":              router.DeliverNewPolicy,
+	"last_per_subject": router.DeliverLastPerSubjectPolicy,
+}
+
+var failurePolicies = map[string]broker.FailurePolicy{
+	"fail_fast": broker.FailFast,
+	"isolate":   broker.Isolate,
+	"restart":   broker.Restart,
+}
+
+// Validate checks the connection URL, the broker failure policy and every route.
+func (c *Config) Validate() error {
+	if c.Connection.URL == "" {
+		return loafernatsx.ErrMissingURL
+	}
+
+	if _, err := c.failurePolicy(); err != nil {
+		return err
+	}
+
+	for i := range c.Routes {
+		r := &c.Routes[i]
+
+		if _, err := r.build(); err != nil {
+			return fmt.Errorf("route %q: %w", r.Label(), err)
+		}
+	}
+
+	return nil
+}
+
+// ConnOptions returns the conn options of the connection settings. Pass them, with
+// Connection.URL, to conn.Connect or conn.Open.
+func (c *Config) ConnOptions() []conn.Option {
+	cc := &c.Connection
+
+	var opts []conn.Option
+
+	if cc.Name != "" {
+		opts = append(opts, conn.WithName(cc.Name))
+	}
+	if cc.Credentials != "" {
+		opts = append(opts, conn.WithCredentials(cc.Credentials))
+	}
+	if cc.ReconnectWait > 0 {
+		opts = append(opts, conn.WithReconnectWait(time.Duration(cc.ReconnectWait)))
+	}
+	if cc.MaxReconnects != nil {
+		opts = append(opts, conn.WithMaxReconnects(*cc.MaxReconnects))
+	}
+	if cc.Timeout > 0 {
+		opts = append(opts, conn.WithTimeout(time.Duration(cc.Timeout)))
+	}
+
+	return opts
+}
+
+// BrokerOptions returns the broker options of the broker settings.
+func (c *Config) BrokerOptions() []broker.Option {
+	bc := &c.Broker
+
+	var opts []broker.Option
+
+	if bc.Workers > 0 {
+		opts = append(opts, broker.WithWorkers(bc.Workers))
+	}
+	if bc.ShutdownTimeout != nil {
+		opts = append(opts, broker.WithShutdownTimeout(time.Duration(*bc.ShutdownTimeout)))
+	}
+	if bc.StatsInterval != nil {
+		opts = append(opts, broker.WithStatsInterval(time.Duration(*bc.StatsInterval)))
+	}
+	if p, ok := failurePolicies[bc.FailurePolicy]; ok {
+		opts = append(opts, broker.WithFailurePolicy(p))
+	}
+	if bc.RestartInitial > 0 || bc.RestartMax > 0 {
+		opts = append(opts, broker.WithRestartBackoff(time.Duration(bc.RestartInitial), time.Duration(bc.RestartMax)))
+	}
+	if bc.RestartAttempts > 0 {
+		opts = append(opts, broker.WithRestartAttempts(bc.RestartAttempts))
+	}
+
+	return opts
+}
+
+// Route creates the route with the given label, its name or its subject. The options
+// that cannot be configured, such as router.WithReply or router.WithOrderingKey, are
+// applied after the configured ones. It returns an error wrapping
+// loafernatsx.ErrRouteNotFound when no route has the label, and one wrapping
+// loafernatsx.ErrDuplicateRoute when several routes have it; name them to tell them apart.
+func (c *Config) Route(label string, opts ...router.Option) (*router.Route, error) {
+	var found *Route
+
+	for i := range c.Routes {
+		if r := &c.Routes[i]; r.Label() == label {
+			if found != nil {
+				return nil, fmt.Errorf("%w: %s", loafernatsx.ErrDuplicateRoute, label)
+			}
+			found = r
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", loafernatsx.ErrRouteNotFound, label)
+	}
+
+	return found.build(opts...)
+}
+
+func (c *Config) failurePolicy() (broker.FailurePolicy, error) {
+	if c.Broker.FailurePolicy == "" {
+		return broker.FailFast, nil
+	}
+
+	p, ok := failurePolicies[c.Broker.FailurePolicy]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown failure policy %q", loafernatsx.ErrInvalidConfig, c.Broker.FailurePolicy)
+	}
+
+	return p, nil
+}
+
+// build creates the router.Route of the definition.
+func (r *Route) build(extra ...router.Option) (*router.Route, error) {
+	routeType, ok := routeTypes[r.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", loafernatsx.ErrUnsupportedType, r.Type)
+	}
+
+	opts, err := r.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return router.New(routeType, r.Subject, append(opts, extra...)...)
+}
+
+// options returns the router options of the settings that are set.
+func (r *Route) options() ([]router.Option, error) {
+	var opts []router.Option
+
+	if r.Name != "" {
+		opts = append(opts, router.WithName(r.Name))
+	}
+	if r.QueueGroup != "" {
+		opts = append(opts, router.WithQueueGroup(r.QueueGroup))
+	}
+	if r.HandlerTimeout > 0 {
+		opts = append(opts, router.WithHandlerTimeout(time.Duration(r.HandlerTimeout)))
+	}
+	if r.Concurrency > 0 {
+		opts = append(opts, router.WithConcurrency(r.Concurrency))
+	}
+	if r.Ordered {
+		opts = append(opts, router.WithOrdered())
+	}
+
+	js, err := r.jetStreamOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, js...)
+
+	return append(opts, r.consumerOptions()...), nil
+}
+
+// jetStreamOptions returns the router options of the JetStream settings that are set.
+func (r *Route) jetStreamOptions() ([]router.Option, error) {
+	var opts []router.Option
+
+	if r.Stream != "" {
+		opts = append(opts, router.WithStream(r.Stream))
+	}
+	if r.Durable != "" {
+		opts = append(opts, router.WithDurable(r.Durable))
+	}
+	if r.AckWait > 0 {
+		opts = append(opts, router.WithAckWait(time.Duration(r.AckWait)))
+	}
+	if r.MaxDeliver != 0 {
+		opts = append(opts, router.WithMaxDeliver(r.MaxDeliver))
+	}
+	if len(r.Backoff) > 0 {
+		delays := make([]time.Duration, 0, len(r.Backoff))
+		for _, d := range r.Backoff {
+			delays = append(delays, time.Duration(d))
+		}
+		opts = append(opts, router.WithBackoff(delays))
+	}
+	if r.EnableDLQ {
+		opts = append(opts, router.WithEnableDLQ())
+	}
+	if r.DLQSubject != "" {
+		opts = append(opts, router.WithDLQSubject(r.DLQSubject))
+	}
+	if r.DeliveryPolicy != "" {
+		p, ok := deliveryPolicies[r.DeliveryPolicy]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown delivery policy %q", loafernatsx.ErrInvalidConfig, r.DeliveryPolicy)
+		}
+		opts = append(opts, router.WithDeliveryPolicy(p))
+	}
+
+	return opts, nil
+}
+
+// consumerOptions returns the router options of the JetStream consumer settings that
+// are set.
+func (r *Route) consumerOptions() []router.Option {
+	var opts []router.Option
+
+	if r.MaxAckPending != 0 {
+		opts = append(opts, router.WithMaxAckPending(r.MaxAckPending))
+	}
+	if r.InactiveThreshold > 0 {
+		opts = append(opts, router.WithInactiveThreshold(time.Duration(r.InactiveThreshold)))
+	}
+	if len(r.FilterSubjects) > 0 {
+		opts = append(opts, router.WithFilterSubjects(r.FilterSubjects...))
+	}
+	if r.HeadersOnly {
+		opts = append(opts, router.WithHeadersOnly())
+	}
+	if r.SampleFrequency != 0 {
+		opts = append(opts, router.WithSampleFrequency(r.SampleFrequency))
+	}
+	if r.MaxRequestBatch != 0 {
+		opts = append(opts, router.WithMaxRequestBatch(r.MaxRequestBatch))
+	}
+	if r.Replicas != 0 {
+		opts = append(opts, router.WithReplicas(r.Replicas))
+	}
+	if r.MemoryStorage {
+		opts = append(opts, router.WithMemoryStorage())
+	}
+	if len(r.Metadata) > 0 {
+		opts = append(opts, router.WithMetadata(r.Metadata))
+	}
+	if r.Description != "" {
+		opts = append(opts, router.WithDescription(r.Description))
+	}
+
+	return opts
+}
@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/broker"
+	"github.com/silviolleite/loafer-natsx/config"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func loadYAML(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg, err := config.Load(config.WithFile(writeConfig(t, "loafer.yaml", yamlConfig)))
+	require.NoError(t, err)
+
+	return cfg
+}
+
+func TestConfig_Route(t *testing.T) {
+	cfg := loadYAML(t)
+
+	r, err := cfg.Route("orders-created")
+	require.NoError(t, err)
+
+	assert.Equal(t, router.TypeJetStream, r.Type())
+	assert.Equal(t, "orders-created", r.Name())
+	assert.Equal(t, "orders.created", r.Subject())
+	assert.Equal(t, "ORDERS", r.Stream())
+	assert.Equal(t, "orders-created", r.Durable())
+	assert.Equal(t, 30*time.Second, r.AckWait())
+	assert.Equal(t, 5, r.MaxDeliver())
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second}, r.Backoff())
+	assert.Equal(t, router.DeliverNewPolicy, r.DeliveryPolicy())
+	assert.True(t, r.DLQEnabled())
+	assert.Equal(t, []string{"orders.created", "orders.updated"}, r.FilterSubjects())
+	assert.Equal(t, 100, r.MaxAckPending())
+	assert.Equal(t, 24*time.Hour, r.InactiveThreshold())
+	assert.True(t, r.HeadersOnly())
+	assert.Equal(t, 10, r.SampleFrequency())
+	assert.Equal(t, 50, r.MaxRequestBatch())
+	assert.Equal(t, 3, r.Replicas())
+	assert.True(t, r.MemoryStorage())
+	assert.Equal(t, map[string]string{"team": "orders"}, r.Metadata())
+	assert.Equal(t, "orders created", r.Description())
+
+	audit, err := cfg.Route("orders.audit", router.WithOrdered())
+	require.NoError(t, err)
+
+	assert.Equal(t, router.TypeQueue, audit.Type())
+	assert.Equal(t, "audit", audit.QueueGroup())
+	assert.Equal(t, 4, audit.Concurrency())
+	assert.True(t, audit.Ordered(), "options given to Route are applied too")
+
+	_, err = cfg.Route("orders.shipped")
+	assert.ErrorIs(t, err, loafernatsx.ErrRouteNotFound)
+}
+
+func TestConfig_RouteSharedLabel(t *testing.T) {
+	body := "connection:\n  url: nats://x\nroutes:\n" +
+		"  - type: pubsub\n    subject: a\n" +
+		"  - type: jetstream\n    subject: a\n    stream: S\n    durable: d\n" +
+		"  - type: jetstream\n    name: b\n    subject: a\n    stream: S\n    durable: b\n"
+
+	cfg, err := config.Load(config.WithFile(writeConfig(t, "c.yaml", body)))
+	require.NoError(t, err, "routes may share a subject")
+
+	_, err = cfg.Route("a")
+	assert.ErrorIs(t, err, loafernatsx.ErrDuplicateRoute)
+
+	r, err := cfg.Route("b")
+	require.NoError(t, err)
+	assert.Equal(t, "b", r.Durable())
+}
+
+func TestConfig_RouteReply(t *testing.T) {
+	cfg, err := config.Load(config.WithFile(writeConfig(t, "loafer.json", jsonConfig)))
+	require.NoError(t, err)
+
+	reply := func(context.Context, any, error) ([]byte, nats.Header, error) { return nil, nil, nil }
+
+	r, err := cfg.Route("orders.get", router.WithReply(reply))
+	require.NoError(t, err)
+
+	assert.Equal(t, router.TypeRequestReply, r.Type())
+	assert.Equal(t, 500*time.Millisecond, r.HandlerTimeout())
+	assert.NotNil(t, r.ReplyFunc())
+}
+
+func TestConfig_Options(t *testing.T) {
+	cfg := loadYAML(t)
+
+	assert.Len(t, cfg.ConnOptions(), 3)
+	assert.Len(t, cfg.BrokerOptions(), 3)
+
+	empty := &config.Config{}
+	assert.Empty(t, empty.ConnOptions())
+	assert.Empty(t, empty.BrokerOptions())
+
+	b := broker.New(nil, nil, cfg.BrokerOptions()...)
+	assert.NotNil(t, b)
+}
@@ -0,0 +1,93 @@
+package config
+
+import "time"
+
+// Config describes a connection, a broker and its routes. It is usually loaded with
+// Load from a YAML or JSON file and environment variables, then turned into options
+// with ConnOptions, BrokerOptions and Route.
+type Config struct {
+	Connection Connection `json:"connection" yaml:"connection"`
+	Routes     []Route    `json:"routes"     yaml:"routes"`
+	Broker     Broker     `json:"broker"     yaml:"broker"`
+}
+
+// Connection holds the settings of conn.Connect. Zero values keep the conn defaults.
+type Connection struct {
+	MaxReconnects *int     `json:"max_reconnects" yaml:"max_reconnects"`
+	URL           string   `json:"url"            yaml:"url"`
+	Name          string   `json:"name"           yaml:"name"`
+	Credentials   string   `json:"credentials"    yaml:"credentials"`
+	ReconnectWait Duration `json:"reconnect_wait" yaml:"reconnect_wait"`
+	Timeout       Duration `json:"timeout"        yaml:"timeout"`
+}
+
+// Broker holds the settings of broker.New. Zero values keep the broker defaults.
+type Broker struct {
+	ShutdownTimeout *Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	StatsInterval   *Duration `json:"stats_interval"   yaml:"stats_interval"`
+	FailurePolicy   string    `json:"failure_policy"   yaml:"failure_policy"`
+	Workers         int       `json:"workers"          yaml:"workers"`
+	RestartInitial  Duration  `json:"restart_initial"  yaml:"restart_initial"`
+	RestartMax      Duration  `json:"restart_max"      yaml:"restart_max"`
+	RestartAttempts int       `json:"restart_attempts" yaml:"restart_attempts"`
+}
+
+// Route holds the definition of a router.Route. Type is one of "pubsub", "queue",
+// "request_reply" and "jetstream"; DeliveryPolicy one of "all", "last", "new" and
+// "last_per_subject". Zero values keep the router defaults.
+type Route struct {
+	Metadata          map[string]string `json:"metadata"           yaml:"metadata"`
+	Name              string            `json:"name"               yaml:"name"`
+	Type              string            `json:"type"               yaml:"type"`
+	Subject           string            `json:"subject"            yaml:"subject"`
+	QueueGroup        string            `json:"queue_group"        yaml:"queue_group"`
+	Stream            string            `json:"stream"             yaml:"stream"`
+	Durable           string            `json:"durable"            yaml:"durable"`
+	DeliveryPolicy    string            `json:"delivery_policy"    yaml:"delivery_policy"`
+	DLQSubject        string            `json:"dlq_subject"        yaml:"dlq_subject"`
+	Description       string            `json:"description"        yaml:"description"`
+	Backoff           []Duration        `json:"backoff"            yaml:"backoff"`
+	FilterSubjects    []string          `json:"filter_subjects"    yaml:"filter_subjects"`
+	AckWait           Duration          `json:"ack_wait"           yaml:"ack_wait"`
+	MaxDeliver        int               `json:"max_deliver"        yaml:"max_deliver"`
+	HandlerTimeout    Duration          `json:"handler_timeout"    yaml:"handler_timeout"`
+	Concurrency       int               `json:"concurrency"        yaml:"concurrency"`
+	MaxAckPending     int               `json:"max_ack_pending"    yaml:"max_ack_pending"`
+	InactiveThreshold Duration          `json:"inactive_threshold" yaml:"inactive_threshold"`
+	SampleFrequency   int               `json:"sample_frequency"   yaml:"sample_frequency"`
+	MaxRequestBatch   int               `json:"max_request_batch"  yaml:"max_request_batch"`
+	Replicas          int               `json:"replicas"           yaml:"replicas"`
+	Ordered           bool              `json:"ordered"            yaml:"ordered"`
+	EnableDLQ         bool              `json:"enable_dlq"         yaml:"enable_dlq"`
+	HeadersOnly       bool              `json:"headers_only"       yaml:"headers_only"`
+	MemoryStorage     bool              `json:"memory_storage"     yaml:"memory_storage"`
+}
+
+// Label returns the route name when set, or the route subject otherwise, like
+// router.Route.Label.
+func (r *Route) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Subject
+}
+
+// Duration is a time.Duration written as a string such as "30s" or "1m30s".
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
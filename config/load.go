@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+)
+
+const defaultEnvPrefix = "LOAFER"
+
+type loadConfig struct {
+	file      string
+	envPrefix string
+}
+
+// LoadOption configures Load.
+type LoadOption func(*loadConfig)
+
+// WithFile reads the configuration from a YAML (.yaml, .yml) or JSON (.json) file.
+// Unknown keys are rejected.
+func WithFile(path string) LoadOption {
+	return func(c *loadConfig) {
+		c.file = path
+	}
+}
+
+// WithEnvPrefix sets the prefix of the environment variables, "LOAFER" by default.
+func WithEnvPrefix(prefix string) LoadOption {
+	return func(c *loadConfig) {
+		c.envPrefix = prefix
+	}
+}
+
+// Load reads the configuration file set with WithFile, if any, then applies the
+// environment variables on top of it and validates the result.
+//
+// The connection and broker settings are read from <PREFIX>_URL, <PREFIX>_NAME,
+// <PREFIX>_CREDENTIALS, <PREFIX>_RECONNECT_WAIT, <PREFIX>_MAX_RECONNECTS,
+// <PREFIX>_TIMEOUT, <PREFIX>_BROKER_WORKERS, <PREFIX>_BROKER_SHUTDOWN_TIMEOUT,
+// <PREFIX>_BROKER_STATS_INTERVAL, <PREFIX>_BROKER_FAILURE_POLICY,
+// <PREFIX>_BROKER_RESTART_INITIAL, <PREFIX>_BROKER_RESTART_MAX and
+// <PREFIX>_BROKER_RESTART_ATTEMPTS. Routes are defined
+// in the file; the settings of a route can be tuned with <PREFIX>_ROUTE_<LABEL>_<KEY>,
+// where LABEL is the route label in upper case with every character other than a
+// letter or digit replaced by "_", and KEY one of STREAM, DURABLE, ACK_WAIT,
+// MAX_DELIVER, HANDLER_TIMEOUT, CONCURRENCY, MAX_ACK_PENDING and DELIVERY_POLICY.
+//
+// Invalid values return an error wrapping loafernatsx.ErrInvalidConfig; invalid
+// settings return the error of conn.Connect or router.New, such as
+// loafernatsx.ErrMissingURL or loafernatsx.ErrMissingDurable.
+func Load(opts ...LoadOption) (*Config, error) {
+	lc := loadConfig{envPrefix: defaultEnvPrefix}
+
+	for _, opt := range opts {
+		opt(&lc)
+	}
+
+	cfg := &Config{}
+
+	if lc.file != "" {
+		if err := readFile(lc.file, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg, lc.envPrefix); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// readFile decodes a YAML or JSON file into cfg, according to its extension.
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", loafernatsx.ErrInvalidConfig, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+
+	default:
+		return fmt.Errorf("%w: unsupported file format %q", loafernatsx.ErrInvalidConfig, path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", loafernatsx.ErrInvalidConfig, path, err)
+	}
+
+	return nil
+}
+
+// envVar binds an environment variable suffix to the setting it overrides.
+type envVar struct {
+	set  func(value string) error
+	name string
+}
+
+// applyEnv overrides the settings of cfg with the environment variables that are set.
+func applyEnv(cfg *Config, prefix string) error {
+	c, b := &cfg.Connection, &cfg.Broker
+
+	vars := []envVar{
+		{name: "URL", set: setString(&c.URL)},
+		{name: "NAME", set: setString(&c.Name)},
+		{name: "CREDENTIALS", set: setString(&c.Credentials)},
+		{name: "RECONNECT_WAIT", set: setDuration(&c.ReconnectWait)},
+		{name: "MAX_RECONNECTS", set: setIntPtr(&c.MaxReconnects)},
+		{name: "TIMEOUT", set: setDuration(&c.Timeout)},
+		{name: "BROKER_WORKERS", set: setInt(&b.Workers)},
+		{name: "BROKER_SHUTDOWN_TIMEOUT", set: setDurationPtr(&b.ShutdownTimeout)},
+		{name: "BROKER_STATS_INTERVAL", set: setDurationPtr(&b.StatsInterval)},
+		{name: "BROKER_FAILURE_POLICY", set: setString(&b.FailurePolicy)},
+		{name: "BROKER_RESTART_INITIAL", set: setDuration(&b.RestartInitial)},
+		{name: "BROKER_RESTART_MAX", set: setDuration(&b.RestartMax)},
+		{name: "BROKER_RESTART_ATTEMPTS", set: setInt(&b.RestartAttempts)},
+	}
+
+	for i := range cfg.Routes {
+		r := &cfg.Routes[i]
+		route := "ROUTE_" + envName(r.Label()) + "_"
+
+		vars = append(vars,
+			envVar{name: route + "STREAM", set: setString(&r.Stream)},
+			envVar{name: route + "DURABLE", set: setString(&r.Durable)},
+			envVar{name: route + "ACK_WAIT", set: setDuration(&r.AckWait)},
+			envVar{name: route + "MAX_DELIVER", set: setInt(&r.MaxDeliver)},
+			envVar{name: route + "HANDLER_TIMEOUT", set: setDuration(&r.HandlerTimeout)},
+			envVar{name: route + "CONCURRENCY", set: setInt(&r.Concurrency)},
+			envVar{name: route + "MAX_ACK_PENDING", set: setInt(&r.MaxAckPending)},
+			envVar{name: route + "DELIVERY_POLICY", set: setString(&r.DeliveryPolicy)},
+		)
+	}
+
+	for _, v := range vars {
+		key := prefix + "_" + v.name
+
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		if err := v.set(value); err != nil {
+			return fmt.Errorf("%w: %s: %w", loafernatsx.ErrInvalidConfig, key, err)
+		}
+	}
+
+	return nil
+}
+
+// envName turns a route label into an environment variable name segment.
+func envName(label string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, label)
+}
+
+func setString(dst *string) func(string) error {
+	return func(v string) error {
+		*dst = v
+		return nil
+	}
+}
+
+func setInt(dst *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func setIntPtr(dst **int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*dst = &n
+		return nil
+	}
+}
+
+func setDuration(dst *Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*dst = Duration(d)
+		return nil
+	}
+}
+
+func setDurationPtr(dst **Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*dst = (*Duration)(&d)
+		return nil
+	}
+}
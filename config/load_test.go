@@ -0,0 +1,242 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/config"
+)
+
+const yamlConfig = `
+connection:
+  url: nats://127.0.0.1:4222
+  name: orders-service
+  reconnect_wait: 2s
+  max_reconnects: 0
+broker:
+  workers: 8
+  shutdown_timeout: 10s
+  failure_policy: restart
+routes:
+  - name: orders-created
+    type: jetstream
+    subject: orders.created
+    stream: ORDERS
+    durable: orders-created
+    ack_wait: 30s
+    max_deliver: 5
+    backoff: [1s, 5s]
+    delivery_policy: new
+    enable_dlq: true
+    filter_subjects: [orders.updated]
+    max_ack_pending: 100
+    inactive_threshold: 24h
+    headers_only: true
+    sample_frequency: 10
+    max_request_batch: 50
+    replicas: 3
+    memory_storage: true
+    metadata:
+      team: orders
+    description: orders created
+  - type: queue
+    subject: orders.audit
+    queue_group: audit
+    concurrency: 4
+`
+
+const jsonConfig = `{
+  "connection": {"url": "nats://127.0.0.1:4222", "timeout": "3s"},
+  "routes": [
+    {"type": "request_reply", "subject": "orders.get", "queue_group": "api", "handler_timeout": "500ms"}
+  ]
+}`
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad_YAML(t *testing.T) {
+	cfg, err := config.Load(config.WithFile(writeConfig(t, "loafer.yaml", yamlConfig)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "nats://127.0.0.1:4222", cfg.Connection.URL)
+	assert.Equal(t, "orders-service", cfg.Connection.Name)
+	assert.Equal(t, config.Duration(2*time.Second), cfg.Connection.ReconnectWait)
+	require.NotNil(t, cfg.Connection.MaxReconnects)
+	assert.Equal(t, 0, *cfg.Connection.MaxReconnects)
+
+	assert.Equal(t, 8, cfg.Broker.Workers)
+	require.NotNil(t, cfg.Broker.ShutdownTimeout)
+	assert.Equal(t, config.Duration(10*time.Second), *cfg.Broker.ShutdownTimeout)
+	assert.Equal(t, "restart", cfg.Broker.FailurePolicy)
+
+	require.Len(t, cfg.Routes, 2)
+	assert.Equal(t, "orders-created", cfg.Routes[0].Label())
+	assert.Equal(t, []config.Duration{config.Duration(time.Second), config.Duration(5 * time.Second)}, cfg.Routes[0].Backoff)
+	assert.Equal(t, "orders.audit", cfg.Routes[1].Label())
+}
+
+func TestLoad_JSON(t *testing.T) {
+	cfg, err := config.Load(config.WithFile(writeConfig(t, "loafer.json", jsonConfig)))
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Duration(3*time.Second), cfg.Connection.Timeout)
+	require.Len(t, cfg.Routes, 1)
+	assert.Equal(t, config.Duration(500*time.Millisecond), cfg.Routes[0].HandlerTimeout)
+}
+
+func TestLoad_Env(t *testing.T) {
+	t.Setenv("ORDERS_NAME", "from-env")
+	t.Setenv("ORDERS_MAX_RECONNECTS", "-1")
+	t.Setenv("ORDERS_BROKER_WORKERS", "2")
+	t.Setenv("ORDERS_BROKER_STATS_INTERVAL", "0s")
+	t.Setenv("ORDERS_BROKER_RESTART_ATTEMPTS", "5")
+	t.Setenv("ORDERS_ROUTE_ORDERS_CREATED_ACK_WAIT", "1m")
+	t.Setenv("ORDERS_ROUTE_ORDERS_CREATED_MAX_DELIVER", "3")
+	t.Setenv("ORDERS_ROUTE_ORDERS_AUDIT_CONCURRENCY", "16")
+	t.Setenv("ORDERS_ROUTE_ORDERS_CREATED_MAX_ACK_PENDING", "-1")
+
+	cfg, err := config.Load(
+		config.WithFile(writeConfig(t, "loafer.yml", yamlConfig)),
+		config.WithEnvPrefix("ORDERS"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-env", cfg.Connection.Name)
+	assert.Equal(t, -1, *cfg.Connection.MaxReconnects)
+	assert.Equal(t, 2, cfg.Broker.Workers)
+	require.NotNil(t, cfg.Broker.StatsInterval)
+	assert.Equal(t, config.Duration(0), *cfg.Broker.StatsInterval)
+	assert.Equal(t, 5, cfg.Broker.RestartAttempts)
+	assert.Equal(t, config.Duration(time.Minute), cfg.Routes[0].AckWait)
+	assert.Equal(t, 3, cfg.Routes[0].MaxDeliver)
+	assert.Equal(t, 16, cfg.Routes[1].Concurrency)
+	assert.Equal(t, -1, cfg.Routes[0].MaxAckPending)
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	t.Setenv("LOAFER_URL", "nats://nats:4222")
+	t.Setenv("LOAFER_TIMEOUT", "1s")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "nats://nats:4222", cfg.Connection.URL)
+	assert.Equal(t, config.Duration(time.Second), cfg.Connection.Timeout)
+	assert.Empty(t, cfg.Routes)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		want error
+		env  map[string]string
+		name string
+		file string
+		body string
+	}{
+		{
+			name: "missing url",
+			file: "c.yaml",
+			body: "broker:\n  workers: 2\n",
+			want: loafernatsx.ErrMissingURL,
+		},
+		{
+			name: "unknown key",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\n  urls: nats://y\n",
+			want: loafernatsx.ErrInvalidConfig,
+		},
+		{
+			name: "unknown json key",
+			file: "c.json",
+			body: `{"connection": {"url": "nats://x"}, "workers": 2}`,
+			want: loafernatsx.ErrInvalidConfig,
+		},
+		{
+			name: "invalid duration",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\n  timeout: soon\n",
+			want: loafernatsx.ErrInvalidConfig,
+		},
+		{
+			name: "unsupported format",
+			file: "c.toml",
+			body: "url = 'nats://x'",
+			want: loafernatsx.ErrInvalidConfig,
+		},
+		{
+			name: "invalid env value",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\n",
+			env:  map[string]string{"LOAFER_BROKER_WORKERS": "many"},
+			want: loafernatsx.ErrInvalidConfig,
+		},
+		{
+			name: "unknown failure policy",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\nbroker:\n  failure_policy: retry\n",
+			want: loafernatsx.ErrInvalidConfig,
+		},
+		{
+			name: "unknown route type",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\nroutes:\n  - type: kafka\n    subject: a\n",
+			want: loafernatsx.ErrUnsupportedType,
+		},
+		{
+			name: "unknown delivery policy",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\nroutes:\n  - type: jetstream\n    subject: a\n" +
+				"    stream: S\n    durable: d\n    delivery_policy: oldest\n",
+			want: loafernatsx.ErrInvalidConfig,
+		},
+		{
+			name: "missing durable",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\nroutes:\n  - type: jetstream\n    subject: a\n    stream: S\n",
+			want: loafernatsx.ErrMissingDurable,
+		},
+		{
+			name: "invalid replicas",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\nroutes:\n  - type: jetstream\n    subject: a\n" +
+				"    stream: S\n    durable: d\n    replicas: 7\n",
+			want: loafernatsx.ErrInvalidReplicas,
+		},
+		{
+			name: "missing queue group",
+			file: "c.yaml",
+			body: "connection:\n  url: nats://x\nroutes:\n  - type: queue\n    subject: a\n",
+			want: loafernatsx.ErrMissingQueueGroup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := config.Load(config.WithFile(writeConfig(t, tt.file, tt.body)))
+			assert.ErrorIs(t, err, tt.want)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := config.Load(config.WithFile(filepath.Join(t.TempDir(), "missing.yaml")))
+	assert.ErrorIs(t, err, loafernatsx.ErrInvalidConfig)
+}
@@ -0,0 +1,172 @@
+package conn
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultCollectorNamespace = "loafer"
+	collectorSubsystem        = "connection"
+)
+
+type collectorConfig struct {
+	constLabels map[string]string
+	namespace   string
+}
+
+// CollectorOption configures a Collector during creation.
+type CollectorOption func(*collectorConfig)
+
+// WithCollectorNamespace sets the prefix of the metric names, "loafer" by default.
+// Use the namespace given to broker.WithMetricsNamespace to keep the connection and
+// broker metrics together.
+func WithCollectorNamespace(ns string) CollectorOption {
+	return func(c *collectorConfig) {
+		if ns != "" {
+			c.namespace = ns
+		}
+	}
+}
+
+// WithCollectorConstLabels adds labels with fixed values, such as the service name,
+// to every metric.
+func WithCollectorConstLabels(labels map[string]string) CollectorOption {
+	return func(c *collectorConfig) {
+		for k, v := range labels {
+			c.constLabels[k] = v
+		}
+	}
+}
+
+// Collector is a prometheus.Collector exporting the state and traffic of a NATS
+// connection. It is also an Observer: register it with WithObserver so it learns
+// the connection and counts its lifecycle events, then register it with the same
+// prometheus.Registerer given to broker.WithMetrics.
+type Collector struct {
+	NopObserver
+	disconnects    prometheus.Counter
+	asyncErrors    prometheus.Counter
+	slowConsumers  prometheus.Counter
+	lameDuckEvents prometheus.Counter
+	nc             atomic.Pointer[nats.Conn]
+	connected      *prometheus.Desc
+	reconnects     *prometheus.Desc
+	inMsgs         *prometheus.Desc
+	outMsgs        *prometheus.Desc
+	inBytes        *prometheus.Desc
+	outBytes       *prometheus.Desc
+}
+
+// NewCollector creates a Collector. Its connection metrics are reported once the
+// connection created with WithObserver(collector) is established.
+func NewCollector(opts ...CollectorOption) *Collector {
+	cfg := collectorConfig{
+		namespace:   defaultCollectorNamespace,
+		constLabels: map[string]string{},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(cfg.namespace, collectorSubsystem, name),
+			help,
+			nil,
+			cfg.constLabels,
+		)
+	}
+
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Subsystem:   collectorSubsystem,
+			Name:        name,
+			Help:        help,
+			ConstLabels: cfg.constLabels,
+		})
+	}
+
+	return &Collector{
+		connected:      desc("connected", "Whether the NATS connection is established (1) or not (0)"),
+		reconnects:     desc("reconnects_total", "Total number of reconnections"),
+		inMsgs:         desc("in_messages_total", "Total number of messages received"),
+		outMsgs:        desc("out_messages_total", "Total number of messages sent"),
+		inBytes:        desc("in_bytes_total", "Total number of payload bytes received"),
+		outBytes:       desc("out_bytes_total", "Total number of payload bytes sent"),
+		disconnects:    counter("disconnects_total", "Total number of disconnections"),
+		asyncErrors:    counter("async_errors_total", "Total number of asynchronous errors"),
+		slowConsumers:  counter("slow_consumers_total", "Total number of slow consumer errors"),
+		lameDuckEvents: counter("lame_duck_events_total", "Total number of lame duck mode notifications"),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connected
+	ch <- c.reconnects
+	ch <- c.inMsgs
+	ch <- c.outMsgs
+	ch <- c.inBytes
+	ch <- c.outBytes
+	c.disconnects.Describe(ch)
+	c.asyncErrors.Describe(ch)
+	c.slowConsumers.Describe(ch)
+	c.lameDuckEvents.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.disconnects.Collect(ch)
+	c.asyncErrors.Collect(ch)
+	c.slowConsumers.Collect(ch)
+	c.lameDuckEvents.Collect(ch)
+
+	nc := c.nc.Load()
+	if nc == nil {
+		return
+	}
+
+	connected := 0.0
+	if nc.IsConnected() {
+		connected = 1
+	}
+
+	stats := nc.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.connected, prometheus.GaugeValue, connected)
+	ch <- prometheus.MustNewConstMetric(c.reconnects, prometheus.CounterValue, float64(stats.Reconnects))
+	ch <- prometheus.MustNewConstMetric(c.inMsgs, prometheus.CounterValue, float64(stats.InMsgs))
+	ch <- prometheus.MustNewConstMetric(c.outMsgs, prometheus.CounterValue, float64(stats.OutMsgs))
+	ch <- prometheus.MustNewConstMetric(c.inBytes, prometheus.CounterValue, float64(stats.InBytes))
+	ch <- prometheus.MustNewConstMetric(c.outBytes, prometheus.CounterValue, float64(stats.OutBytes))
+}
+
+// Connected implements Observer.
+func (c *Collector) Connected(nc *nats.Conn) {
+	c.nc.Store(nc)
+}
+
+// Disconnected implements Observer.
+func (c *Collector) Disconnected(*nats.Conn, error) {
+	c.disconnects.Inc()
+}
+
+// AsyncError implements Observer.
+func (c *Collector) AsyncError(_ *nats.Conn, _ *nats.Subscription, err error) {
+	c.asyncErrors.Inc()
+
+	if errors.Is(err, nats.ErrSlowConsumer) {
+		c.slowConsumers.Inc()
+	}
+}
+
+// LameDuck implements Observer.
+func (c *Collector) LameDuck(*nats.Conn) {
+	c.lameDuckEvents.Inc()
+}
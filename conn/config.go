@@ -1,10 +1,28 @@
 package conn
 
-import "time"
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/silviolleite/loafer-natsx/logger"
+)
 
 type config struct {
+	log           logger.Logger
+	tlsConfig     *tls.Config
 	name          string
+	caFile        string
+	certFile      string
+	keyFile       string
+	user          string
+	password      string
+	token         string
+	nkeySeedFile  string
+	credsFile     string
+	observers     observers
 	reconnectWait time.Duration
 	maxReconnects int
 	timeout       time.Duration
+	userInfo      bool
+	tokenSet      bool
 }
@@ -19,6 +19,9 @@ const (
 )
 
 // Connect establishes a connection to a NATS server and returns a *nats.Conn.
+// TLS and authentication settings are validated before connecting; at most one
+// authentication method (user and password, token, NKey seed or credentials file)
+// can be configured.
 func Connect(url string, opts ...Option) (*nats.Conn, error) {
 	if url == "" {
 		return nil, loafernatsx.ErrMissingURL
@@ -35,18 +38,35 @@ func Connect(url string, opts ...Option) (*nats.Conn, error) {
 		opt(&cfg)
 	}
 
+	security, err := securityOptions(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	options := []nats.Option{
 		nats.Name(cfg.name),
 		nats.Timeout(cfg.timeout),
 		nats.MaxReconnects(cfg.maxReconnects),
 		nats.ReconnectWait(cfg.reconnectWait),
 	}
+	options = append(options, security...)
+
+	obs := cfg.observers
+	if cfg.log != nil {
+		obs = append(observers{logObserver{log: cfg.log}}, obs...)
+	}
+
+	if len(obs) > 0 {
+		options = append(options, obs.natsOptions()...)
+	}
 
 	nc, err := nats.Connect(url, options...)
 	if err != nil {
 		return nil, err
 	}
 
+	obs.connected(nc)
+
 	return nc, nil
 }
 
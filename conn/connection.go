@@ -0,0 +1,86 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+)
+
+// Connection owns a NATS connection and its JetStream context, so consumers, brokers
+// and producers can share them. Pass it to consumer.New and broker.New with their
+// WithConnection options, and JetStream() to the producer strategies.
+type Connection struct {
+	nc     *nats.Conn
+	js     jetstream.JetStream
+	closed chan struct{}
+}
+
+// Open connects to a NATS server like Connect and creates the JetStream context of the
+// connection.
+func Open(url string, opts ...Option) (*Connection, error) {
+	closed := make(chan struct{})
+
+	opts = append(slices.Clip(opts), WithObserver(closeObserver{closed: closed}))
+
+	nc, err := Connect(url, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	return &Connection{nc: nc, js: js, closed: closed}, nil
+}
+
+// NATS returns the underlying NATS connection.
+func (c *Connection) NATS() *nats.Conn {
+	return c.nc
+}
+
+// JetStream returns the JetStream context of the connection.
+func (c *Connection) JetStream() jetstream.JetStream {
+	return c.js
+}
+
+// Close drains the connection: subscriptions stop receiving messages, the messages
+// already received are handled, pending publishes are flushed and the connection is
+// closed. Close blocks until the connection is closed. When ctx is done first, the
+// connection is closed without waiting for the drain, and Close returns an error
+// wrapping loafernatsx.ErrDrainTimeout. Closing a closed connection returns nil.
+func (c *Connection) Close(ctx context.Context) error {
+	err := c.nc.Drain()
+	if err != nil && !errors.Is(err, nats.ErrConnectionClosed) && !errors.Is(err, nats.ErrConnectionDraining) {
+		c.nc.Close()
+		<-c.closed
+		return err
+	}
+
+	select {
+	case <-c.closed:
+		return nil
+	case <-ctx.Done():
+		c.nc.Close()
+		<-c.closed
+		return fmt.Errorf("%w: %w", loafernatsx.ErrDrainTimeout, ctx.Err())
+	}
+}
+
+// closeObserver signals when the connection is closed.
+type closeObserver struct {
+	NopObserver
+	closed chan struct{}
+}
+
+func (o closeObserver) Closed(*nats.Conn) {
+	close(o.closed)
+}
@@ -0,0 +1,100 @@
+package conn_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/conn"
+)
+
+func TestOpen(t *testing.T) {
+	url := runServerWith(t, func(opts *server.Options) {
+		opts.JetStream = true
+		opts.StoreDir = t.TempDir()
+	})
+
+	obs := &recordingObserver{}
+
+	c, err := conn.Open(url, conn.WithName("orders"), conn.WithObserver(obs))
+	require.NoError(t, err)
+
+	assert.True(t, c.NATS().IsConnected())
+	assert.Equal(t, "orders", c.NATS().Opts.Name)
+	assert.True(t, obs.has("connected"))
+
+	_, err = c.JetStream().CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "ORDERS",
+		Subjects: []string{"orders.>"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Close(context.Background()))
+	assert.True(t, c.NATS().IsClosed())
+	assert.True(t, obs.has("closed"))
+
+	assert.NoError(t, c.Close(context.Background()), "closing a closed connection")
+}
+
+func TestOpen_InvalidURL(t *testing.T) {
+	c, err := conn.Open("")
+	assert.ErrorIs(t, err, loafernatsx.ErrMissingURL)
+	assert.Nil(t, c)
+}
+
+func TestConnection_CloseDrainsSubscriptions(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	c, err := conn.Open(url)
+	require.NoError(t, err)
+
+	var handled atomic.Int32
+
+	_, err = c.NATS().Subscribe("drain", func(*nats.Msg) {
+		time.Sleep(50 * time.Millisecond)
+		handled.Add(1)
+	})
+	require.NoError(t, err)
+
+	for range 3 {
+		require.NoError(t, c.NATS().Publish("drain", []byte("data")))
+	}
+	require.NoError(t, c.NATS().Flush())
+
+	require.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, int32(3), handled.Load())
+}
+
+func TestConnection_CloseTimeout(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	c, err := conn.Open(url)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	_, err = c.NATS().Subscribe("slow", func(*nats.Msg) { <-release })
+	require.NoError(t, err)
+
+	require.NoError(t, c.NATS().Publish("slow", []byte("data")))
+	require.NoError(t, c.NATS().Flush())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = c.Close(ctx)
+	assert.ErrorIs(t, err, loafernatsx.ErrDrainTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, c.NATS().IsClosed())
+}
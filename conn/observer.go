@@ -0,0 +1,148 @@
+package conn
+
+import (
+	"github.com/nats-io/nats.go"
+
+	"github.com/silviolleite/loafer-natsx/logger"
+)
+
+// Observer is notified of the connection lifecycle events. The nats client runs the
+// callbacks one at a time on a dedicated goroutine, so implementations should return
+// quickly. Embed NopObserver to implement only some of the methods.
+type Observer interface {
+	// Connected is called once Connect established the connection.
+	Connected(nc *nats.Conn)
+
+	// Disconnected is called when the connection is lost; err is nil for a requested disconnect.
+	Disconnected(nc *nats.Conn, err error)
+
+	// Reconnected is called when the connection is established again.
+	Reconnected(nc *nats.Conn)
+
+	// Closed is called when the connection is closed and will not reconnect anymore.
+	Closed(nc *nats.Conn)
+
+	// AsyncError is called for errors that happen outside of a call, such as slow
+	// consumers or permission violations. sub is nil when the error is not tied to a
+	// subscription.
+	AsyncError(nc *nats.Conn, sub *nats.Subscription, err error)
+
+	// DiscoveredServers is called when the server announces new servers of the cluster.
+	DiscoveredServers(nc *nats.Conn)
+
+	// LameDuck is called when the server enters lame duck mode before shutting down,
+	// so the connection will move to another server.
+	LameDuck(nc *nats.Conn)
+}
+
+// NopObserver is an Observer that ignores every event.
+type NopObserver struct{}
+
+// Connected implements Observer.
+func (NopObserver) Connected(*nats.Conn) {}
+
+// Disconnected implements Observer.
+func (NopObserver) Disconnected(*nats.Conn, error) {}
+
+// Reconnected implements Observer.
+func (NopObserver) Reconnected(*nats.Conn) {}
+
+// Closed implements Observer.
+func (NopObserver) Closed(*nats.Conn) {}
+
+// AsyncError implements Observer.
+func (NopObserver) AsyncError(*nats.Conn, *nats.Subscription, error) {}
+
+// DiscoveredServers implements Observer.
+func (NopObserver) DiscoveredServers(*nats.Conn) {}
+
+// LameDuck implements Observer.
+func (NopObserver) LameDuck(*nats.Conn) {}
+
+// logObserver logs the connection lifecycle events.
+type logObserver struct {
+	log logger.Logger
+}
+
+func (o logObserver) Connected(nc *nats.Conn) {
+	o.log.Info("nats connected", "name", nc.Opts.Name, "url", nc.ConnectedUrlRedacted())
+}
+
+func (o logObserver) Disconnected(nc *nats.Conn, err error) {
+	if err != nil {
+		o.log.Error("nats disconnected", "name", nc.Opts.Name, "error", err)
+		return
+	}
+
+	o.log.Info("nats disconnected", "name", nc.Opts.Name)
+}
+
+func (o logObserver) Reconnected(nc *nats.Conn) {
+	o.log.Info("nats reconnected", "name", nc.Opts.Name, "url", nc.ConnectedUrlRedacted())
+}
+
+func (o logObserver) Closed(nc *nats.Conn) {
+	o.log.Info("nats connection closed", "name", nc.Opts.Name)
+}
+
+func (o logObserver) AsyncError(nc *nats.Conn, sub *nats.Subscription, err error) {
+	if sub != nil {
+		o.log.Error("nats async error", "name", nc.Opts.Name, "subject", sub.Subject, "error", err)
+		return
+	}
+
+	o.log.Error("nats async error", "name", nc.Opts.Name, "error", err)
+}
+
+func (o logObserver) DiscoveredServers(nc *nats.Conn) {
+	o.log.Info("nats servers discovered", "name", nc.Opts.Name, "servers", nc.DiscoveredServers())
+}
+
+func (o logObserver) LameDuck(nc *nats.Conn) {
+	o.log.Info("nats server entered lame duck mode", "name", nc.Opts.Name, "url", nc.ConnectedUrlRedacted())
+}
+
+// observers fans the events out to every configured observer.
+type observers []Observer
+
+// natsOptions returns the nats handlers that notify the observers.
+func (obs observers) natsOptions() []nats.Option {
+	return []nats.Option{
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			for _, o := range obs {
+				o.Disconnected(nc, err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			for _, o := range obs {
+				o.Reconnected(nc)
+			}
+		}),
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			for _, o := range obs {
+				o.Closed(nc)
+			}
+		}),
+		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			for _, o := range obs {
+				o.AsyncError(nc, sub, err)
+			}
+		}),
+		nats.DiscoveredServersHandler(func(nc *nats.Conn) {
+			for _, o := range obs {
+				o.DiscoveredServers(nc)
+			}
+		}),
+		nats.LameDuckModeHandler(func(nc *nats.Conn) {
+			for _, o := range obs {
+				o.LameDuck(nc)
+			}
+		}),
+	}
+}
+
+func (obs observers) connected(nc *nats.Conn) {
+	for _, o := range obs {
+		o.Connected(nc)
+	}
+}
@@ -0,0 +1,214 @@
+package conn_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/conn"
+)
+
+type recordingLogger struct {
+	msgs []string
+	mu   sync.Mutex
+}
+
+func (l *recordingLogger) add(level, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, level+": "+msg)
+}
+
+func (l *recordingLogger) Info(msg string, _ ...any)  { l.add("info", msg) }
+func (l *recordingLogger) Error(msg string, _ ...any) { l.add("error", msg) }
+func (l *recordingLogger) Debug(msg string, _ ...any) { l.add("debug", msg) }
+
+func (l *recordingLogger) has(entry string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, m := range l.msgs {
+		if m == entry {
+			return true
+		}
+	}
+	return false
+}
+
+type recordingObserver struct {
+	conn.NopObserver
+	events []string
+	mu     sync.Mutex
+}
+
+func (o *recordingObserver) add(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *recordingObserver) has(event string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, e := range o.events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *recordingObserver) Connected(*nats.Conn)           { o.add("connected") }
+func (o *recordingObserver) Disconnected(*nats.Conn, error) { o.add("disconnected") }
+func (o *recordingObserver) Reconnected(*nats.Conn)         { o.add("reconnected") }
+func (o *recordingObserver) Closed(*nats.Conn)              { o.add("closed") }
+func (o *recordingObserver) LameDuck(*nats.Conn)            { o.add("lame duck") }
+func (o *recordingObserver) DiscoveredServers(*nats.Conn)   { o.add("discovered") }
+func (o *recordingObserver) AsyncError(_ *nats.Conn, _ *nats.Subscription, err error) {
+	o.add("async error: " + err.Error())
+}
+
+func TestConnect_LifecycleEvents(t *testing.T) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	s := natstest.RunServer(&opts)
+
+	port := s.Addr().(*net.TCPAddr).Port
+
+	log := &recordingLogger{}
+	obs := &recordingObserver{}
+
+	nc, err := conn.Connect(
+		s.ClientURL(),
+		conn.WithLogger(log),
+		conn.WithObserver(obs),
+		conn.WithObserver(nil),
+		conn.WithReconnectWait(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	assert.True(t, obs.has("connected"))
+	assert.True(t, log.has("info: nats connected"))
+
+	s.Shutdown()
+
+	require.Eventually(t, func() bool { return obs.has("disconnected") }, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, log.has("error: nats disconnected"))
+
+	restartOpts := natstest.DefaultTestOptions
+	restartOpts.Port = port
+	s = natstest.RunServer(&restartOpts)
+	defer s.Shutdown()
+
+	require.Eventually(t, func() bool { return obs.has("reconnected") }, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, log.has("info: nats reconnected"))
+
+	nc.Close()
+
+	require.Eventually(t, func() bool { return obs.has("closed") }, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, log.has("info: nats connection closed"))
+}
+
+func TestConnect_LameDuck(t *testing.T) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.LameDuckDuration = 500 * time.Millisecond
+	opts.LameDuckGracePeriod = 100 * time.Millisecond
+	s := natstest.RunServer(&opts)
+
+	obs := &recordingObserver{}
+
+	nc, err := conn.Connect(s.ClientURL(), conn.WithObserver(obs))
+	require.NoError(t, err)
+	defer nc.Close()
+
+	go s.LameDuckShutdown()
+
+	require.Eventually(t, func() bool { return obs.has("lame duck") }, 2*time.Second, 10*time.Millisecond)
+
+	s.WaitForShutdown()
+}
+
+func TestCollector(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	col := conn.NewCollector(
+		conn.WithCollectorNamespace("svc"),
+		conn.WithCollectorConstLabels(map[string]string{"service": "orders"}),
+	)
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(col))
+
+	assert.Equal(t, 0.0, collectorValue(t, reg, "svc_connection_disconnects_total"))
+	_, ok := findMetric(t, reg, "svc_connection_connected")
+	assert.False(t, ok, "connection metrics are reported once connected")
+
+	nc, err := conn.Connect(url, conn.WithObserver(col))
+	require.NoError(t, err)
+
+	sub, err := nc.Subscribe("collector.slow", func(*nats.Msg) { time.Sleep(50 * time.Millisecond) })
+	require.NoError(t, err)
+	require.NoError(t, sub.SetPendingLimits(1, 1024))
+
+	for range 10 {
+		require.NoError(t, nc.Publish("collector.slow", []byte("data")))
+	}
+	require.NoError(t, nc.Flush())
+
+	assert.Equal(t, 1.0, collectorValue(t, reg, "svc_connection_connected"))
+	assert.Equal(t, 10.0, collectorValue(t, reg, "svc_connection_out_messages_total"))
+	assert.Equal(t, 40.0, collectorValue(t, reg, "svc_connection_out_bytes_total"))
+
+	require.Eventually(t, func() bool {
+		return collectorValue(t, reg, "svc_connection_slow_consumers_total") >= 1 &&
+			collectorValue(t, reg, "svc_connection_async_errors_total") >= 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	m, _ := findMetric(t, reg, "svc_connection_connected")
+	assert.Equal(t, "service", m.GetLabel()[0].GetName())
+	assert.Equal(t, "orders", m.GetLabel()[0].GetValue())
+
+	nc.Close()
+
+	assert.Equal(t, 0.0, collectorValue(t, reg, "svc_connection_connected"))
+	assert.Equal(t, 1.0, collectorValue(t, reg, "svc_connection_disconnects_total"))
+}
+
+// findMetric returns the metric with the given name gathered from reg.
+func findMetric(t *testing.T, reg *prometheus.Registry, name string) (*dto.Metric, bool) {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, f := range families {
+		if f.GetName() == name && len(f.GetMetric()) > 0 {
+			return f.GetMetric()[0], true
+		}
+	}
+	return nil, false
+}
+
+// collectorValue returns the value of the gauge or counter with the given name.
+func collectorValue(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+
+	m, ok := findMetric(t, reg, name)
+	require.True(t, ok, "metric %s not found", name)
+
+	if m.GetGauge() != nil {
+		return m.GetGauge().GetValue()
+	}
+	return m.GetCounter().GetValue()
+}
@@ -1,6 +1,11 @@
 package conn
 
-import "time"
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/silviolleite/loafer-natsx/logger"
+)
 
 // Option is a functional option type used to configure connection settings dynamically.
 type Option func(*config)
@@ -32,3 +37,78 @@ func WithTimeout(d time.Duration) Option {
 		c.timeout = d
 	}
 }
+
+// WithTLSConfig sets the TLS configuration of the connection. Files set with WithRootCA
+// and WithClientCert are added to a copy of cfg.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *config) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithRootCA sets a PEM file with the certificate authorities used to verify the server.
+func WithRootCA(caFile string) Option {
+	return func(c *config) {
+		c.caFile = caFile
+	}
+}
+
+// WithClientCert sets the PEM certificate and key files presented to the server for
+// mutual TLS. Both files are required.
+func WithClientCert(certFile, keyFile string) Option {
+	return func(c *config) {
+		c.certFile = certFile
+		c.keyFile = keyFile
+	}
+}
+
+// WithUserInfo authenticates the connection with a user and password.
+func WithUserInfo(user, password string) Option {
+	return func(c *config) {
+		c.userInfo = true
+		c.user = user
+		c.password = password
+	}
+}
+
+// WithToken authenticates the connection with a token.
+func WithToken(token string) Option {
+	return func(c *config) {
+		c.tokenSet = true
+		c.token = token
+	}
+}
+
+// WithNKeySeed authenticates the connection with the NKey seed stored in seedFile.
+func WithNKeySeed(seedFile string) Option {
+	return func(c *config) {
+		c.nkeySeedFile = seedFile
+	}
+}
+
+// WithCredentials authenticates the connection with a .creds file holding a user JWT
+// and its NKey seed, as generated by nsc.
+func WithCredentials(credsFile string) Option {
+	return func(c *config) {
+		c.credsFile = credsFile
+	}
+}
+
+// WithLogger logs the connection lifecycle events: connects, disconnects, reconnects,
+// closes, asynchronous errors such as slow consumers, discovered servers and lame
+// duck mode.
+func WithLogger(log logger.Logger) Option {
+	return func(c *config) {
+		c.log = log
+	}
+}
+
+// WithObserver registers an Observer notified of the connection lifecycle events, such
+// as a Collector. It can be used several times; nil observers are ignored.
+func WithObserver(o Observer) Option {
+	return func(c *config) {
+		if o != nil {
+			c.observers = append(c.observers, o)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package conn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/nats-io/nats.go"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+)
+
+// securityOptions validates the TLS and authentication settings and returns the
+// matching nats options.
+func securityOptions(cfg *config) ([]nats.Option, error) {
+	var opts []nats.Option
+
+	tlsOpt, err := tlsOption(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsOpt != nil {
+		opts = append(opts, tlsOpt)
+	}
+
+	authOpt, err := authOption(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if authOpt != nil {
+		opts = append(opts, authOpt)
+	}
+
+	return opts, nil
+}
+
+// tlsOption builds the TLS configuration from the configured *tls.Config and files.
+// It returns nil when TLS is not configured.
+func tlsOption(cfg *config) (nats.Option, error) {
+	if cfg.tlsConfig == nil && cfg.caFile == "" && cfg.certFile == "" && cfg.keyFile == "" {
+		return nil, nil
+	}
+
+	if (cfg.certFile == "") != (cfg.keyFile == "") {
+		return nil, loafernatsx.ErrIncompleteClientCert
+	}
+
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.tlsConfig != nil {
+		tlsCfg = cfg.tlsConfig.Clone()
+	}
+
+	if cfg.caFile != "" {
+		pem, err := os.ReadFile(cfg.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", loafernatsx.ErrInvalidTLS, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates found in %s", loafernatsx.ErrInvalidTLS, cfg.caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.certFile, cfg.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", loafernatsx.ErrInvalidTLS, err)
+		}
+		tlsCfg.Certificates = append(tlsCfg.Certificates, cert)
+	}
+
+	return nats.Secure(tlsCfg), nil
+}
+
+// authOption returns the option of the single configured authentication method, or
+// nil when none is configured.
+func authOption(cfg *config) (nats.Option, error) {
+	var (
+		opt     nats.Option
+		methods int
+	)
+
+	if cfg.userInfo {
+		methods++
+		if cfg.user == "" {
+			return nil, loafernatsx.ErrMissingUser
+		}
+		opt = nats.UserInfo(cfg.user, cfg.password)
+	}
+
+	if cfg.tokenSet {
+		methods++
+		if cfg.token == "" {
+			return nil, loafernatsx.ErrMissingToken
+		}
+		opt = nats.Token(cfg.token)
+	}
+
+	if cfg.nkeySeedFile != "" {
+		methods++
+		nkeyOpt, err := nats.NkeyOptionFromSeed(cfg.nkeySeedFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", loafernatsx.ErrInvalidCredentials, err)
+		}
+		opt = nkeyOpt
+	}
+
+	if cfg.credsFile != "" {
+		methods++
+		// The file is read again on every (re)connect; check it up front to fail fast.
+		if _, err := os.Stat(cfg.credsFile); err != nil {
+			return nil, fmt.Errorf("%w: %w", loafernatsx.ErrInvalidCredentials, err)
+		}
+		opt = nats.UserCredentials(cfg.credsFile)
+	}
+
+	if methods > 1 {
+		return nil, loafernatsx.ErrConflictingAuth
+	}
+
+	return opt, nil
+}
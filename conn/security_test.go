@@ -0,0 +1,353 @@
+package conn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/conn"
+)
+
+// testPKI holds a certificate authority with a server and a client certificate, as PEM files.
+type testPKI struct {
+	server   tls.Certificate
+	pool     *x509.CertPool
+	caFile   string
+	certFile string
+	keyFile  string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, kErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, kErr)
+
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			DNSNames:     []string{"localhost"},
+		}
+		der, cErr := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+		require.NoError(t, cErr)
+
+		keyDER, mErr := x509.MarshalECPrivateKey(key)
+		require.NoError(t, mErr)
+
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	serverCert, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	server, err := tls.X509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+
+	clientCert, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+
+	p := &testPKI{
+		server:   server,
+		pool:     pool,
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "client.pem"),
+		keyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	writeFile(t, p.caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+	writeFile(t, p.certFile, clientCert)
+	writeFile(t, p.keyFile, clientKey)
+
+	return p
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func runServerWith(t *testing.T, configure func(opts *server.Options)) string {
+	t.Helper()
+
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	configure(&opts)
+
+	s := natstest.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+
+	return s.ClientURL()
+}
+
+func TestConnect_TLSFiles(t *testing.T) {
+	pki := newTestPKI(t)
+
+	url := runServerWith(t, func(opts *server.Options) {
+		opts.TLS = true
+		opts.TLSVerify = true
+		opts.TLSConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{pki.server},
+			ClientCAs:    pki.pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		}
+	})
+
+	nc, err := conn.Connect(
+		url,
+		conn.WithRootCA(pki.caFile),
+		conn.WithClientCert(pki.certFile, pki.keyFile),
+	)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	assert.True(t, nc.IsConnected())
+
+	_, err = conn.Connect(url, conn.WithRootCA(pki.caFile), conn.WithTimeout(time.Second))
+	assert.Error(t, err, "the server requires a client certificate")
+}
+
+func TestConnect_TLSConfig(t *testing.T) {
+	pki := newTestPKI(t)
+
+	url := runServerWith(t, func(opts *server.Options) {
+		opts.TLS = true
+		opts.TLSConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{pki.server},
+		}
+	})
+
+	nc, err := conn.Connect(url, conn.WithTLSConfig(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pki.pool,
+	}))
+	require.NoError(t, err)
+	defer nc.Close()
+
+	assert.True(t, nc.IsConnected())
+}
+
+func TestConnect_TLSValidation(t *testing.T) {
+	pki := newTestPKI(t)
+
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	writeFile(t, notPEM, []byte("not a certificate"))
+
+	tests := []struct {
+		want error
+		name string
+		opts []conn.Option
+	}{
+		{
+			name: "cert without key",
+			opts: []conn.Option{conn.WithClientCert(pki.certFile, "")},
+			want: loafernatsx.ErrIncompleteClientCert,
+		},
+		{
+			name: "missing ca file",
+			opts: []conn.Option{conn.WithRootCA(filepath.Join(t.TempDir(), "missing.pem"))},
+			want: loafernatsx.ErrInvalidTLS,
+		},
+		{
+			name: "ca file without certificates",
+			opts: []conn.Option{conn.WithRootCA(notPEM)},
+			want: loafernatsx.ErrInvalidTLS,
+		},
+		{
+			name: "mismatched key",
+			opts: []conn.Option{conn.WithClientCert(pki.certFile, pki.caFile)},
+			want: loafernatsx.ErrInvalidTLS,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nc, err := conn.Connect("nats://127.0.0.1:4222", tt.opts...)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Nil(t, nc)
+		})
+	}
+}
+
+func TestConnect_UserInfo(t *testing.T) {
+	url := runServerWith(t, func(opts *server.Options) {
+		opts.Username = "loafer"
+		opts.Password = "secret"
+	})
+
+	nc, err := conn.Connect(url, conn.WithUserInfo("loafer", "secret"))
+	require.NoError(t, err)
+	nc.Close()
+
+	_, err = conn.Connect(url, conn.WithUserInfo("loafer", "wrong"), conn.WithTimeout(time.Second))
+	assert.Error(t, err)
+}
+
+func TestConnect_Token(t *testing.T) {
+	url := runServerWith(t, func(opts *server.Options) {
+		opts.Authorization = "s3cr3t"
+	})
+
+	nc, err := conn.Connect(url, conn.WithToken("s3cr3t"))
+	require.NoError(t, err)
+	nc.Close()
+}
+
+func TestConnect_NKeySeed(t *testing.T) {
+	kp, err := nkeys.CreateUser()
+	require.NoError(t, err)
+
+	pub, err := kp.PublicKey()
+	require.NoError(t, err)
+
+	seed, err := kp.Seed()
+	require.NoError(t, err)
+
+	seedFile := filepath.Join(t.TempDir(), "user.nk")
+	writeFile(t, seedFile, seed)
+
+	url := runServerWith(t, func(opts *server.Options) {
+		opts.Nkeys = []*server.NkeyUser{{Nkey: pub}}
+	})
+
+	nc, err := conn.Connect(url, conn.WithNKeySeed(seedFile))
+	require.NoError(t, err)
+	nc.Close()
+}
+
+func TestConnect_Credentials(t *testing.T) {
+	operator, err := nkeys.CreateOperator()
+	require.NoError(t, err)
+	operatorPub, err := operator.PublicKey()
+	require.NoError(t, err)
+
+	account, err := nkeys.CreateAccount()
+	require.NoError(t, err)
+	accountPub, err := account.PublicKey()
+	require.NoError(t, err)
+
+	user, err := nkeys.CreateUser()
+	require.NoError(t, err)
+	userPub, err := user.PublicKey()
+	require.NoError(t, err)
+	userSeed, err := user.Seed()
+	require.NoError(t, err)
+
+	operatorJWT, err := jwt.NewOperatorClaims(operatorPub).Encode(operator)
+	require.NoError(t, err)
+	operatorClaims, err := jwt.DecodeOperatorClaims(operatorJWT)
+	require.NoError(t, err)
+
+	accountJWT, err := jwt.NewAccountClaims(accountPub).Encode(operator)
+	require.NoError(t, err)
+
+	userJWT, err := jwt.NewUserClaims(userPub).Encode(account)
+	require.NoError(t, err)
+
+	creds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	require.NoError(t, err)
+
+	credsFile := filepath.Join(t.TempDir(), "user.creds")
+	writeFile(t, credsFile, creds)
+
+	resolver := &server.MemAccResolver{}
+	require.NoError(t, resolver.Store(accountPub, accountJWT))
+
+	url := runServerWith(t, func(opts *server.Options) {
+		opts.TrustedOperators = []*jwt.OperatorClaims{operatorClaims}
+		opts.AccountResolver = resolver
+	})
+
+	nc, err := conn.Connect(url, conn.WithCredentials(credsFile))
+	require.NoError(t, err)
+	nc.Close()
+}
+
+func TestConnect_AuthValidation(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		want error
+		name string
+		opts []conn.Option
+	}{
+		{
+			name: "empty user",
+			opts: []conn.Option{conn.WithUserInfo("", "secret")},
+			want: loafernatsx.ErrMissingUser,
+		},
+		{
+			name: "empty token",
+			opts: []conn.Option{conn.WithToken("")},
+			want: loafernatsx.ErrMissingToken,
+		},
+		{
+			name: "missing nkey seed",
+			opts: []conn.Option{conn.WithNKeySeed(missing)},
+			want: loafernatsx.ErrInvalidCredentials,
+		},
+		{
+			name: "missing creds file",
+			opts: []conn.Option{conn.WithCredentials(missing)},
+			want: loafernatsx.ErrInvalidCredentials,
+		},
+		{
+			name: "several methods",
+			opts: []conn.Option{conn.WithUserInfo("loafer", "secret"), conn.WithToken("s3cr3t")},
+			want: loafernatsx.ErrConflictingAuth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nc, err := conn.Connect("nats://127.0.0.1:4222", tt.opts...)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Nil(t, nc)
+		})
+	}
+}
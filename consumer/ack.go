@@ -0,0 +1,87 @@
+package consumer
+
+import (
+	"fmt"
+	"time"
+)
+
+// Acknowledger gives handlers explicit control over JetStream acknowledgements.
+// Messages delivered through JetStream routes implement it, so a handler can
+// type-assert its Message to extend the ack deadline of a long job or settle
+// the message itself:
+//
+//	if ack, ok := msg.(consumer.Acknowledger); ok {
+//		_ = ack.InProgress()
+//	}
+//
+// When the handler settles the message with Ack, Nak, NakWithDelay, Term or
+// TermWithReason, the consumer skips its automatic acknowledgement.
+type Acknowledger interface {
+	// Ack acknowledges the message as successfully processed.
+	Ack() error
+
+	// Nak requests an immediate redelivery of the message.
+	Nak() error
+
+	// NakWithDelay requests a redelivery of the message after the given delay.
+	NakWithDelay(delay time.Duration) error
+
+	// Term stops redelivery of the message regardless of MaxDeliver.
+	Term() error
+
+	// TermWithReason stops redelivery of the message and reports the reason in the server advisory.
+	TermWithReason(reason string) error
+
+	// InProgress resets the server redelivery timer, extending the ack deadline.
+	InProgress() error
+}
+
+// TerminalError marks a handler error as permanent. The consumer terminates the
+// message instead of requesting a redelivery, or publishes it to the DLQ when the
+// route has the DLQ enabled.
+type TerminalError struct {
+	Err error
+}
+
+// Terminate wraps err in a TerminalError so the message is not redelivered.
+func Terminate(err error) error {
+	return &TerminalError{Err: err}
+}
+
+// Error returns the wrapped error message.
+func (e *TerminalError) Error() string {
+	if e.Err == nil {
+		return "terminal error"
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *TerminalError) Unwrap() error {
+	return e.Err
+}
+
+// RetryError asks the consumer to request a redelivery of the message after Delay
+// instead of an immediate redelivery.
+type RetryError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// RetryAfter wraps err in a RetryError so the message is redelivered after delay.
+func RetryAfter(err error, delay time.Duration) error {
+	return &RetryError{Err: err, Delay: delay}
+}
+
+// Error returns the wrapped error message together with the requested delay.
+func (e *RetryError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("retry after %s", e.Delay)
+	}
+	return fmt.Sprintf("%s (retry after %s)", e.Err.Error(), e.Delay)
+}
+
+// Unwrap returns the wrapped error.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
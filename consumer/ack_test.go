@@ -0,0 +1,413 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func TestTerminalError(t *testing.T) {
+	cause := errors.New("poison")
+	err := consumer.Terminate(cause)
+
+	var terminal *consumer.TerminalError
+	assert.True(t, errors.As(err, &terminal))
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "poison", err.Error())
+	assert.Equal(t, "terminal error", (&consumer.TerminalError{}).Error())
+}
+
+func TestRetryError(t *testing.T) {
+	cause := errors.New("downstream unavailable")
+	err := consumer.RetryAfter(cause, 2*time.Second)
+
+	var retry *consumer.RetryError
+	assert.True(t, errors.As(err, &retry))
+	assert.Equal(t, 2*time.Second, retry.Delay)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "downstream unavailable (retry after 2s)", err.Error())
+	assert.Equal(t, "retry after 1s", (&consumer.RetryError{Delay: time.Second}).Error())
+}
+
+func TestNewMessage_NotAcknowledger(t *testing.T) {
+	_, ok := consumer.NewMessage(&nats.Msg{}).(consumer.Acknowledger)
+	assert.False(t, ok)
+}
+
+func setupJetStream(t *testing.T, stream, subject string) (*nats.Conn, jetstream.JetStream) {
+	t.Helper()
+
+	s, url := runServer(true)
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	createStream(t, js, stream, subject)
+
+	return nc, js
+}
+
+// createStream recreates the stream so every test starts from an empty state.
+func createStream(t *testing.T, js jetstream.JetStream, stream, subject string) {
+	t.Helper()
+
+	_ = js.DeleteStream(context.Background(), stream)
+	_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: []string{subject},
+	})
+	require.NoError(t, err)
+}
+
+func TestJetStream_Terminate_NoRedelivery(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTTERM", "test.term")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.term",
+		router.WithStream("TESTTERM"),
+		router.WithDurable("dterm"),
+		router.WithAckWait(200*time.Millisecond),
+	)
+
+	var calls atomic.Int32
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		calls.Add(1)
+		return nil, consumer.Terminate(errors.New("poison"))
+	})
+	assert.NoError(t, err)
+
+	_, _ = js.Publish(context.Background(), "test.term", []byte("data"))
+
+	time.Sleep(600 * time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestJetStream_Terminate_PublishesToDLQ(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTTERMDLQ", "test.termdlq")
+	createStream(t, js, "TESTTERMDLQ_DLQ", "dlq.test.termdlq")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.termdlq",
+		router.WithStream("TESTTERMDLQ"),
+		router.WithDurable("dtermdlq"),
+		router.WithMaxDeliver(5),
+		router.WithEnableDLQ(),
+	)
+
+	dlq := make(chan *nats.Msg, 1)
+	_, _ = nc.Subscribe("dlq.test.termdlq", func(msg *nats.Msg) {
+		dlq <- msg
+	})
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		return nil, consumer.Terminate(errors.New("poison"))
+	})
+	assert.NoError(t, err)
+
+	_, _ = js.Publish(context.Background(), "test.termdlq", []byte("data"))
+
+	select {
+	case msg := <-dlq:
+		assert.Equal(t, "poison", msg.Header.Get(consumer.HeaderErrorKey))
+		assert.Equal(t, "1", msg.Header.Get(consumer.HeaderRetryCountKey))
+	case <-time.After(3 * time.Second):
+		t.Fatal("terminal message not published to DLQ")
+	}
+}
+
+func TestJetStream_RetryAfter_DelaysRedelivery(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTRETRY", "test.retry")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.retry",
+		router.WithStream("TESTRETRY"),
+		router.WithDurable("dretry"),
+	)
+
+	var calls atomic.Int32
+	deliveries := make(chan time.Time, 2)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		deliveries <- time.Now()
+		if calls.Add(1) == 1 {
+			return nil, consumer.RetryAfter(errors.New("busy"), 300*time.Millisecond)
+		}
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	_, _ = js.Publish(context.Background(), "test.retry", []byte("data"))
+
+	first := <-deliveries
+	select {
+	case second := <-deliveries:
+		assert.GreaterOrEqual(t, second.Sub(first), 250*time.Millisecond)
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not redelivered")
+	}
+}
+
+func TestJetStream_ExplicitAck_SkipsAutomaticNak(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTEXPLICIT", "test.explicit")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.explicit",
+		router.WithStream("TESTEXPLICIT"),
+		router.WithDurable("dexplicit"),
+	)
+
+	var calls atomic.Int32
+
+	err := c.StartMessage(ctx, r, func(ctx context.Context, msg consumer.Message) (any, error) {
+		calls.Add(1)
+
+		ack, ok := msg.(consumer.Acknowledger)
+		if !ok {
+			return nil, errors.New("not an acknowledger")
+		}
+
+		if pErr := ack.InProgress(); pErr != nil {
+			return nil, pErr
+		}
+
+		_ = ack.Ack()
+		return nil, errors.New("error after explicit ack")
+	})
+	assert.NoError(t, err)
+
+	_, _ = js.Publish(context.Background(), "test.explicit", []byte("data"))
+
+	assert.Eventually(t, func() bool {
+		cons, cErr := js.Consumer(context.Background(), "TESTEXPLICIT", "dexplicit")
+		if cErr != nil {
+			return false
+		}
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.AckFloor.Stream == 1 && info.NumAckPending == 0
+	}, 2*time.Second, 20*time.Millisecond)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestJetStream_Backoff_DelaysNak(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTBACKOFF", "test.backoff")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.backoff",
+		router.WithStream("TESTBACKOFF"),
+		router.WithDurable("dbackoff"),
+		router.WithBackoff(router.FixedBackoff(300*time.Millisecond)),
+	)
+
+	var calls atomic.Int32
+	deliveries := make(chan time.Time, 2)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		deliveries <- time.Now()
+		if calls.Add(1) == 1 {
+			return nil, errors.New("fail")
+		}
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	_, _ = js.Publish(context.Background(), "test.backoff", []byte("data"))
+
+	first := <-deliveries
+	select {
+	case second := <-deliveries:
+		assert.GreaterOrEqual(t, second.Sub(first), 250*time.Millisecond)
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not redelivered")
+	}
+}
+
+func TestJetStream_Backoff_KeepsAckWait(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTBACKOFFACK", "test.backoff.ack")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.backoff.ack",
+		router.WithStream("TESTBACKOFFACK"),
+		router.WithDurable("dbackoffack"),
+		router.WithBackoff(router.FixedBackoff(200*time.Millisecond, time.Second)),
+	)
+
+	var calls atomic.Int32
+
+	err := c.Start(ctx, r, func(context.Context, []byte) (any, error) {
+		calls.Add(1)
+		time.Sleep(600 * time.Millisecond)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	cons, err := js.Consumer(context.Background(), "TESTBACKOFFACK", "dbackoffack")
+	require.NoError(t, err)
+
+	cfg := cons.CachedInfo().Config
+	assert.Equal(t, 30*time.Second, cfg.AckWait)
+	assert.Empty(t, cfg.BackOff, "a first delay shorter than AckWait is not sent to the consumer")
+
+	_, err = js.Publish(context.Background(), "test.backoff.ack", []byte("data"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.AckFloor.Stream == 1 && info.NumAckPending == 0
+	}, 3*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestJetStream_Backoff_SentWhenNotShorterThanAckWait(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTBACKOFFCFG", "test.backoff.cfg")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.backoff.cfg",
+		router.WithStream("TESTBACKOFFCFG"),
+		router.WithDurable("dbackoffcfg"),
+		router.WithAckWait(time.Second),
+		router.WithBackoff(router.FixedBackoff(time.Second, 5*time.Second)),
+	)
+
+	require.NoError(t, c.Start(ctx, r, func(context.Context, []byte) (any, error) { return nil, nil }))
+
+	cons, err := js.Consumer(context.Background(), "TESTBACKOFFCFG", "dbackoffcfg")
+	require.NoError(t, err)
+
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second}, cons.CachedInfo().Config.BackOff)
+}
+
+// noMetadataJetStream hands out messages whose metadata cannot be parsed.
+type noMetadataJetStream struct {
+	jetstream.JetStream
+}
+
+func (j noMetadataJetStream) CreateOrUpdateConsumer(
+	ctx context.Context,
+	stream string,
+	cfg jetstream.ConsumerConfig,
+) (jetstream.Consumer, error) {
+	cons, err := j.JetStream.CreateOrUpdateConsumer(ctx, stream, cfg)
+	return noMetadataConsumer{Consumer: cons}, err
+}
+
+type noMetadataConsumer struct {
+	jetstream.Consumer
+}
+
+func (c noMetadataConsumer) Consume(
+	handler jetstream.MessageHandler,
+	opts ...jetstream.PullConsumeOpt,
+) (jetstream.ConsumeContext, error) {
+	return c.Consumer.Consume(func(msg jetstream.Msg) { handler(noMetadataMsg{Msg: msg}) }, opts...)
+}
+
+type noMetadataMsg struct {
+	jetstream.Msg
+}
+
+func (noMetadataMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return nil, errors.New("no metadata")
+}
+
+func TestJetStream_FailureWithoutMetadataIsNaked(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTNOMETA", "test.nometa")
+
+	c, _ := consumer.New(nc, logger.NopLogger{}, consumer.WithJetStream(noMetadataJetStream{JetStream: js}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.nometa",
+		router.WithStream("TESTNOMETA"),
+		router.WithDurable("dnometa"),
+		router.WithEnableDLQ(),
+	)
+
+	var calls atomic.Int32
+
+	err := c.Start(ctx, r, func(context.Context, []byte) (any, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("fail")
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_, err = js.Publish(context.Background(), "test.nometa", []byte("data"))
+	require.NoError(t, err)
+
+	// The default AckWait is 30s, so an ack within a few seconds proves the failure was Nak'ed.
+	cons, err := js.Consumer(context.Background(), "TESTNOMETA", "dnometa")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.AckFloor.Stream == 1 && info.NumAckPending == 0
+	}, 3*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,176 @@
+package consumer
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// BatchHandlerFunc defines the function signature for batch processing.
+// It returns one result per message, in the same order as msgs: a nil entry
+// acknowledges the message, while a non-nil entry is handled like an error returned
+// by a single-message handler (Terminate, RetryAfter, backoff and DLQ all apply).
+// Returning a nil slice acknowledges the whole batch. Messages settled explicitly
+// through Acknowledger are left untouched.
+type BatchHandlerFunc func(ctx context.Context, msgs []Message) []error
+
+// FailBatch returns a result slice that fails every message of the batch with err.
+func FailBatch(msgs []Message, err error) []error {
+	results := make([]error, len(msgs))
+	for i := range results {
+		results[i] = err
+	}
+	return results
+}
+
+// StartBatch begins pull-based batch consumption for a JetStream route configured
+// with router.WithBatch. Messages are fetched in batches of up to the route batch
+// size, waiting at most the route batch max wait, and every batch is handed to the
+// handler in a single call. Batches are processed sequentially; the route handler
+// timeout applies to the whole batch. The middlewares do not apply to batches; a
+// Tracer starts one span per message, ended with the message result, but the
+// handler context does not carry them, as every message has its own parent.
+func (p *Consumer) StartBatch(ctx context.Context, route *router.Route, handler BatchHandlerFunc) error {
+	if route.Type() != router.TypeJetStream {
+		return loafernatsx.ErrBatchRequiresJetStream
+	}
+
+	if route.BatchSize() <= 0 {
+		return loafernatsx.ErrInvalidBatchSize
+	}
+
+	cons, err := p.js.CreateOrUpdateConsumer(ctx, route.Stream(), jetStreamConsumerConfig(route))
+	if err != nil {
+		return err
+	}
+
+	f := newInflight(ctx, route)
+	p.track(f)
+
+	go func() {
+		defer f.close()
+		p.pullBatches(ctx, f.ctx, route, cons, handler)
+	}()
+
+	return nil
+}
+
+// pullBatches fetches and processes batches until ctx is canceled. The batch being
+// handled when ctx is canceled is completed, with hctx, before it returns.
+func (p *Consumer) pullBatches(
+	ctx context.Context,
+	hctx context.Context,
+	route *router.Route,
+	cons jetstream.Consumer,
+	handler BatchHandlerFunc,
+) {
+	for ctx.Err() == nil {
+		msgs, err := fetchBatch(ctx, route, cons)
+		if err != nil {
+			p.logger.Error("fetch error", "subject", route.Subject(), "error", err)
+		}
+
+		if len(msgs) == 0 {
+			if err != nil {
+				// Avoid a tight loop while the server is unreachable.
+				sleep(ctx, route.BatchMaxWait())
+			}
+			continue
+		}
+
+		p.safeHandle(hctx, route, func(ctx context.Context) {
+			p.handleBatch(ctx, route, handler, msgs)
+		})
+	}
+}
+
+// fetchBatch pulls up to the route batch size, returning whatever arrived within
+// the batch max wait. Messages received before an error are returned with it.
+func fetchBatch(ctx context.Context, route *router.Route, cons jetstream.Consumer) ([]jetstream.Msg, error) {
+	fctx, cancel := context.WithTimeout(ctx, route.BatchMaxWait())
+	defer cancel()
+
+	batch, err := cons.Fetch(route.BatchSize(), jetstream.FetchContext(fctx))
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]jetstream.Msg, 0, route.BatchSize())
+	for msg := range batch.Messages() {
+		msgs = append(msgs, msg)
+	}
+
+	if bErr := batch.Error(); bErr != nil && fctx.Err() == nil {
+		return msgs, bErr
+	}
+
+	return msgs, nil
+}
+
+func (p *Consumer) handleBatch(
+	ctx context.Context,
+	route *router.Route,
+	handler BatchHandlerFunc,
+	msgs []jetstream.Msg,
+) {
+	jsMsgs := make([]*jetStreamMessage, len(msgs))
+	in := make([]Message, len(msgs))
+
+	for i, msg := range msgs {
+		jsMsgs[i] = &jetStreamMessage{msg: msg}
+		in[i] = jsMsgs[i]
+
+		p.observeDelivery(route, msg)
+	}
+
+	ends := p.startBatchSpans(ctx, route, in)
+
+	results, bErr := invokeBatch(ctx, route, handler, in)
+	if bErr == nil && results != nil && len(results) != len(msgs) {
+		bErr = loafernatsx.ErrBatchResultMismatch
+	}
+
+	for i, jsMsg := range jsMsgs {
+		hErr := bErr
+		if hErr == nil && results != nil {
+			hErr = results[i]
+		}
+
+		p.settle(route, jsMsg, hErr)
+
+		if ends != nil {
+			ends[i](hErr)
+		}
+	}
+}
+
+// startBatchSpans starts a span for every message of a batch when a Tracer is
+// configured, and returns the functions that end them.
+func (p *Consumer) startBatchSpans(ctx context.Context, route *router.Route, msgs []Message) []func(err error) {
+	if p.tracer == nil {
+		return nil
+	}
+
+	ctx = contextWithRoute(ctx, route)
+	ends := make([]func(err error), len(msgs))
+
+	for i, msg := range msgs {
+		_, ends[i] = p.tracer.Start(ctx, msg)
+	}
+
+	return ends
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,254 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func TestFailBatch(t *testing.T) {
+	cause := errors.New("db down")
+	msgs := []consumer.Message{consumer.NewMessage(&nats.Msg{}), consumer.NewMessage(&nats.Msg{})}
+
+	assert.Equal(t, []error{cause, cause}, consumer.FailBatch(msgs, cause))
+}
+
+func TestStartBatch_Validation(t *testing.T) {
+	s, url := runServer(true)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	handler := func(context.Context, []consumer.Message) []error { return nil }
+
+	pubsub, _ := router.New(router.TypePubSub, "test.batch")
+	assert.ErrorIs(t, c.StartBatch(context.Background(), pubsub, handler), loafernatsx.ErrBatchRequiresJetStream)
+
+	js, _ := router.New(router.TypeJetStream, "test.batch", router.WithStream("S"), router.WithDurable("d"))
+	assert.ErrorIs(t, c.StartBatch(context.Background(), js, handler), loafernatsx.ErrInvalidBatchSize)
+}
+
+func TestStartBatch_DeliversPendingMessagesTogether(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTBATCH", "test.batch")
+
+	for i := range 5 {
+		_, err := js.Publish(context.Background(), "test.batch", []byte(strconv.Itoa(i)))
+		require.NoError(t, err)
+	}
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.batch",
+		router.WithStream("TESTBATCH"),
+		router.WithDurable("dbatch"),
+		router.WithBatch(10, 300*time.Millisecond),
+	)
+
+	batches := make(chan []string, 10)
+
+	err := c.StartBatch(ctx, r, func(_ context.Context, msgs []consumer.Message) []error {
+		data := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			data = append(data, string(msg.Data()))
+		}
+		batches <- data
+		return nil
+	})
+	require.NoError(t, err)
+
+	select {
+	case batch := <-batches:
+		assert.Equal(t, []string{"0", "1", "2", "3", "4"}, batch)
+	case <-time.After(3 * time.Second):
+		t.Fatal("batch not delivered")
+	}
+
+	// Every message was acknowledged, so nothing is redelivered.
+	select {
+	case batch := <-batches:
+		t.Fatalf("unexpected redelivery: %v", batch)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestStartBatch_PerMessageResults(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTBATCHRES", "test.batchres")
+
+	for _, data := range []string{"a", "b", "c"} {
+		_, err := js.Publish(context.Background(), "test.batchres", []byte(data))
+		require.NoError(t, err)
+	}
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.batchres",
+		router.WithStream("TESTBATCHRES"),
+		router.WithDurable("dbatchres"),
+		router.WithBatch(10, 200*time.Millisecond),
+	)
+
+	var mu sync.Mutex
+	deliveries := map[string]int{}
+
+	err := c.StartBatch(ctx, r, func(_ context.Context, msgs []consumer.Message) []error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		results := make([]error, len(msgs))
+		for i, msg := range msgs {
+			deliveries[string(msg.Data())]++
+			if string(msg.Data()) == "b" && deliveries["b"] == 1 {
+				results[i] = errors.New("fail")
+			}
+		}
+		return results
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return deliveries["b"] == 2
+	}, 3*time.Second, 20*time.Millisecond)
+
+	time.Sleep(300 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, deliveries)
+}
+
+func TestStartBatch_ResultMismatchFailsBatch(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTBATCHMISMATCH", "test.batchmismatch")
+
+	for range 2 {
+		_, err := js.Publish(context.Background(), "test.batchmismatch", []byte("data"))
+		require.NoError(t, err)
+	}
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.batchmismatch",
+		router.WithStream("TESTBATCHMISMATCH"),
+		router.WithDurable("dbatchmismatch"),
+		router.WithBatch(10, 200*time.Millisecond),
+	)
+
+	var mu sync.Mutex
+	var sizes []int
+
+	err := c.StartBatch(ctx, r, func(_ context.Context, msgs []consumer.Message) []error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		sizes = append(sizes, len(msgs))
+		if len(sizes) == 1 {
+			return []error{nil}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		total := 0
+		for _, n := range sizes {
+			total += n
+		}
+		return total == 4
+	}, 3*time.Second, 20*time.Millisecond)
+}
+
+// recordingTracer records the subjects and results of the spans it starts.
+type recordingTracer struct {
+	ended map[string]error
+	mu    sync.Mutex
+}
+
+func (r *recordingTracer) Start(ctx context.Context, msg consumer.Message) (context.Context, func(err error)) {
+	return ctx, func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.ended[string(msg.Data())] = err
+	}
+}
+
+func TestStartBatch_TracesEveryMessage(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTBATCHTRACE", "test.batchtrace")
+
+	for _, data := range []string{"a", "b"} {
+		_, err := js.Publish(context.Background(), "test.batchtrace", []byte(data))
+		require.NoError(t, err)
+	}
+
+	tracer := &recordingTracer{ended: map[string]error{}}
+	c, _ := consumer.New(nc, logger.NopLogger{}, consumer.WithTracer(tracer))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.batchtrace",
+		router.WithStream("TESTBATCHTRACE"),
+		router.WithDurable("dbatchtrace"),
+		router.WithBatch(10, 200*time.Millisecond),
+		router.WithMaxDeliver(1),
+	)
+
+	fail := errors.New("fail")
+
+	err := c.StartBatch(ctx, r, func(_ context.Context, msgs []consumer.Message) []error {
+		results := make([]error, len(msgs))
+		for i, msg := range msgs {
+			if string(msg.Data()) == "b" {
+				results[i] = fail
+			}
+		}
+		return results
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		tracer.mu.Lock()
+		defer tracer.mu.Unlock()
+		return len(tracer.ended) == 2
+	}, 3*time.Second, 20*time.Millisecond)
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	assert.Equal(t, map[string]error{"a": nil, "b": fail}, tracer.ended)
+}
@@ -0,0 +1,17 @@
+package consumer
+
+import (
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const defaultConcurrency = 1
+
+type config struct {
+	nc          *nats.Conn
+	js          jetstream.JetStream
+	tracer      Tracer
+	observer    Observer
+	middlewares []Middleware
+	concurrency int
+}
@@ -2,40 +2,98 @@ package consumer
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 
 	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/reply"
 
 	loafernatsx "github.com/silviolleite/loafer-natsx"
 	"github.com/silviolleite/loafer-natsx/router"
 )
 
+const (
+	// dlqPublishAttempts is how many times a DLQ publish is tried on the last delivery
+	// of a message, when a failure would leave the message without a redelivery.
+	dlqPublishAttempts = 5
+
+	// dlqRetryDelay is the first delay between two DLQ publish attempts.
+	dlqRetryDelay = 200 * time.Millisecond
+
+	// dlqRetryFactor is the growth factor between consecutive DLQ publish delays.
+	dlqRetryFactor = 2
+)
+
 // Consumer handles message consumption using defined routes and handlers.
 type Consumer struct {
-	nc     *nats.Conn
-	js     jetstream.JetStream
-	logger logger.Logger
+	nc          *nats.Conn
+	js          jetstream.JetStream
+	logger      logger.Logger
+	observer    Observer
+	tracer      Tracer
+	failures    *failureTracker
+	middlewares []Middleware
+	inflight    []*inflight
+	concurrency int
+	mu          sync.Mutex
 }
 
-// New creates a new Consumer instance.
-func New(nc *nats.Conn, log logger.Logger) (*Consumer, error) {
+// New creates a new Consumer instance with the given NATS connection, logger, and optional configuration options.
+func New(nc *nats.Conn, log logger.Logger, opts ...Option) (*Consumer, error) {
+	cfg := config{
+		concurrency: defaultConcurrency,
+		observer:    NopObserver{},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	if log == nil {
 		log = logger.NopLogger{}
 	}
 
-	js, err := jetstream.New(nc)
-	if err != nil {
-		return nil, err
+	if cfg.nc != nil {
+		nc = cfg.nc
 	}
 
-	return &Consumer{nc: nc, js: js, logger: log}, nil
+	js := cfg.js
+	if js == nil {
+		var err error
+		if js, err = jetstream.New(nc); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Consumer{
+		nc:          nc,
+		js:          js,
+		logger:      log,
+		failures:    newFailureTracker(),
+		observer:    cfg.observer,
+		tracer:      cfg.tracer,
+		middlewares: middlewares(&cfg),
+		concurrency: cfg.concurrency,
+	}, nil
 }
 
 // Start begins consuming messages based on the provided route and handler.
+// The handler receives only the message payload; use StartMessage to access
+// headers, the concrete subject and JetStream metadata.
 func (p *Consumer) Start(ctx context.Context, route *router.Route, handler HandlerFunc) error {
+	return p.StartMessage(ctx, route, AdaptHandler(handler))
+}
+
+// StartMessage begins consuming messages based on the provided route and message-aware handler.
+func (p *Consumer) StartMessage(ctx context.Context, route *router.Route, handler MessageHandlerFunc) error {
+	handler = Chain(handler, p.middlewares...)
+
 	switch route.Type() {
 	case router.TypePubSub:
 		return p.startPubSub(ctx, route, handler)
@@ -53,13 +111,21 @@ func (p *Consumer) Start(ctx context.Context, route *router.Route, handler Handl
 	return loafernatsx.ErrUnsupportedType
 }
 
-func (p *Consumer) startPubSub(ctx context.Context, route *router.Route, handler HandlerFunc) error {
+func (p *Consumer) startPubSub(ctx context.Context, route *router.Route, handler MessageHandlerFunc) error {
+	d := p.dispatcherFor(route)
+	f := newInflight(ctx, route)
+
 	sub, err := p.nc.Subscribe(route.Subject(), func(msg *nats.Msg) {
-		p.safeHandle(ctx, msg.Subject, func() {
-			_, err := handler(ctx, msg.Data)
-			if err != nil {
-				p.logger.Error("handler error", "subject", msg.Subject, "error", err)
-			}
+		f.begin()
+		d.dispatch(msg.Subject, msg.Header, func() {
+			defer f.end()
+
+			p.safeHandle(f.ctx, route, func(ctx context.Context) {
+				_, err := invoke(ctx, route, handler, NewMessage(msg))
+				if err != nil {
+					p.logHandlerError(route, msg.Subject, err)
+				}
+			})
 		})
 	})
 
@@ -67,40 +133,56 @@ func (p *Consumer) startPubSub(ctx context.Context, route *router.Route, handler
 		return err
 	}
 
-	p.drainOnCancel(ctx, func() { _ = sub.Drain() })
+	p.drainOnCancel(ctx, f, func() { p.drainSubscription(sub) })
 
 	return nil
 }
 
-func (p *Consumer) startQueue(ctx context.Context, route *router.Route, handler HandlerFunc) error {
+func (p *Consumer) startQueue(ctx context.Context, route *router.Route, handler MessageHandlerFunc) error {
+	d := p.dispatcherFor(route)
+	f := newInflight(ctx, route)
+
 	sub, err := p.nc.QueueSubscribe(route.Subject(), route.QueueGroup(), func(msg *nats.Msg) {
-		p.safeHandle(ctx, msg.Subject, func() {
-			_, err := handler(ctx, msg.Data)
-			if err != nil {
-				p.logger.Error("handler error", "subject", msg.Subject, "error", err)
-			}
+		f.begin()
+		d.dispatch(msg.Subject, msg.Header, func() {
+			defer f.end()
+
+			p.safeHandle(f.ctx, route, func(ctx context.Context) {
+				_, err := invoke(ctx, route, handler, NewMessage(msg))
+				if err != nil {
+					p.logHandlerError(route, msg.Subject, err)
+				}
+			})
 		})
 	})
 	if err != nil {
 		return err
 	}
 
-	p.drainOnCancel(ctx, func() { _ = sub.Drain() })
+	p.drainOnCancel(ctx, f, func() { p.drainSubscription(sub) })
 
 	return nil
 }
 
-func (p *Consumer) startRequestReply(ctx context.Context, route *router.Route, handler HandlerFunc) error {
+func (p *Consumer) startRequestReply(ctx context.Context, route *router.Route, handler MessageHandlerFunc) error {
+	d := p.dispatcherFor(route)
+	f := newInflight(ctx, route)
+
 	sub, err := p.nc.QueueSubscribe(route.Subject(), route.QueueGroup(), func(msg *nats.Msg) {
-		p.safeHandle(ctx, msg.Subject, func() {
-			p.handleRequestReplyMessage(ctx, route, handler, msg)
+		f.begin()
+		d.dispatch(msg.Subject, msg.Header, func() {
+			defer f.end()
+
+			p.safeHandle(f.ctx, route, func(ctx context.Context) {
+				p.handleRequestReplyMessage(ctx, route, handler, msg)
+			})
 		})
 	})
 	if err != nil {
 		return err
 	}
 
-	p.drainOnCancel(ctx, func() { _ = sub.Drain() })
+	p.drainOnCancel(ctx, f, func() { p.drainSubscription(sub) })
 
 	return nil
 }
@@ -108,22 +190,30 @@ func (p *Consumer) startRequestReply(ctx context.Context, route *router.Route, h
 func (p *Consumer) handleRequestReplyMessage(
 	ctx context.Context,
 	route *router.Route,
-	handler HandlerFunc,
+	handler MessageHandlerFunc,
 	msg *nats.Msg,
 ) {
-	result, hErr := handler(ctx, msg.Data)
+	result, hErr := invoke(ctx, route, handler, NewMessage(msg))
 
 	if route.ReplyFunc() == nil {
-		p.defaultReply(msg, hErr)
+		p.defaultReply(route, msg, hErr)
 		return
 	}
 
+	if errors.Is(hErr, loafernatsx.ErrHandlerTimeout) {
+		p.logHandlerError(route, msg.Subject, hErr)
+	}
+
 	data, headers, rErr := route.ReplyFunc()(ctx, result, hErr)
 	if rErr != nil {
 		p.logger.Error("reply builder error", "subject", msg.Subject, "error", rErr)
 		return
 	}
 
+	p.sendReply(msg, data, headers)
+}
+
+func (p *Consumer) sendReply(msg *nats.Msg, data []byte, headers nats.Header) {
 	out := &nats.Msg{
 		Subject: msg.Reply,
 		Data:    data,
@@ -137,9 +227,18 @@ func (p *Consumer) handleRequestReplyMessage(
 	}
 }
 
-func (p *Consumer) defaultReply(msg *nats.Msg, err error) {
+func (p *Consumer) defaultReply(route *router.Route, msg *nats.Msg, err error) {
+	if errors.Is(err, loafernatsx.ErrHandlerTimeout) {
+		p.logHandlerError(route, msg.Subject, err)
+
+		// Reply with an error instead of staying silent so the requester does not wait for its own deadline.
+		data, headers := reply.WithError(err)
+		p.sendReply(msg, data, headers)
+		return
+	}
+
 	if err != nil {
-		p.logger.Error("handler error", "subject", msg.Subject, "error", err)
+		p.logHandlerError(route, msg.Subject, err)
 		return
 	}
 
@@ -148,50 +247,122 @@ func (p *Consumer) defaultReply(msg *nats.Msg, err error) {
 	}
 }
 
-func (p *Consumer) startJetStream(ctx context.Context, route *router.Route, handler HandlerFunc) error {
-	consumerCfg := jetstream.ConsumerConfig{
-		Durable:       route.Durable(),
-		AckPolicy:     jetstream.AckExplicitPolicy,
-		DeliverPolicy: jetstream.DeliverPolicy(route.DeliveryPolicy()),
-		MaxDeliver:    route.MaxDeliver(),
-		AckWait:       route.AckWait(),
-		FilterSubject: route.Subject(),
-	}
-
-	cons, err := p.js.CreateOrUpdateConsumer(ctx, route.Stream(), consumerCfg)
+func (p *Consumer) startJetStream(ctx context.Context, route *router.Route, handler MessageHandlerFunc) error {
+	cons, err := p.js.CreateOrUpdateConsumer(ctx, route.Stream(), jetStreamConsumerConfig(route))
 	if err != nil {
 		return err
 	}
 
+	d := p.dispatcherFor(route)
+	f := newInflight(ctx, route)
+
 	consumeCtx, err := cons.Consume(func(msg jetstream.Msg) {
-		p.safeHandle(ctx, route.Subject(), func() {
-			p.handleJetStreamMessage(ctx, route, handler, msg)
+		f.begin()
+		d.dispatch(msg.Subject(), msg.Headers(), func() {
+			defer f.end()
+
+			p.safeHandle(f.ctx, route, func(ctx context.Context) {
+				p.handleJetStreamMessage(ctx, route, handler, msg)
+			})
 		})
 	})
 	if err != nil {
 		return err
 	}
 
-	p.drainOnCancel(ctx, func() { consumeCtx.Stop() })
+	p.drainOnCancel(ctx, f, func() { p.drainConsumer(consumeCtx) })
 
 	return nil
 }
 
+func jetStreamConsumerConfig(route *router.Route) jetstream.ConsumerConfig {
+	cfg := jetstream.ConsumerConfig{
+		Durable:           route.Durable(),
+		Description:       route.Description(),
+		AckPolicy:         jetstream.AckExplicitPolicy,
+		DeliverPolicy:     jetstream.DeliverPolicy(route.DeliveryPolicy()),
+		MaxDeliver:        route.MaxDeliver(),
+		AckWait:           route.AckWait(),
+		MaxAckPending:     route.MaxAckPending(),
+		MaxRequestBatch:   route.MaxRequestBatch(),
+		InactiveThreshold: route.InactiveThreshold(),
+		HeadersOnly:       route.HeadersOnly(),
+		Replicas:          route.Replicas(),
+		MemoryStorage:     route.MemoryStorage(),
+		Metadata:          route.Metadata(),
+	}
+
+	// JetStream uses the first BackOff delay as the ack deadline of every delivery, so
+	// the schedule is only sent when it does not shorten AckWait; otherwise it is only
+	// used for the Nak delays.
+	if b := route.Backoff(); len(b) > 0 && b[0] >= route.AckWait() {
+		cfg.BackOff = b
+	}
+
+	// JetStream accepts either a single filter subject or several, not both.
+	if subjects := route.FilterSubjects(); len(subjects) > 1 {
+		cfg.FilterSubjects = subjects
+	} else {
+		cfg.FilterSubject = route.Subject()
+	}
+
+	if f := route.SampleFrequency(); f > 0 {
+		cfg.SampleFrequency = strconv.Itoa(f) + "%"
+	}
+
+	return cfg
+}
+
 func (p *Consumer) handleJetStreamMessage(
 	ctx context.Context,
 	route *router.Route,
-	handler HandlerFunc,
+	handler MessageHandlerFunc,
 	msg jetstream.Msg,
 ) {
-	meta, _ := msg.Metadata()
-	_, hErr := handler(ctx, msg.Data())
+	jsMsg := &jetStreamMessage{msg: msg}
+
+	p.observeDelivery(route, msg)
+
+	_, hErr := invoke(ctx, route, handler, jsMsg)
+
+	p.settle(route, jsMsg, hErr)
+}
+
+// settle acknowledges a JetStream message according to the handler outcome,
+// unless the handler already acknowledged it explicitly.
+func (p *Consumer) settle(route *router.Route, jsMsg *jetStreamMessage, hErr error) {
+	msg := jsMsg.msg
+
+	meta, err := msg.Metadata()
+	if err != nil {
+		// The delivery count is unknown: failures are handled as a first delivery.
+		p.logger.Error("metadata error", "subject", route.Subject(), "error", err)
+		meta = nil
+	}
+
+	if jsMsg.isSettled() {
+		// The handler took explicit control of the acknowledgement.
+		if route.DLQEnabled() {
+			p.failures.forget(meta)
+		}
+		if hErr != nil {
+			p.logHandlerError(route, msg.Subject(), hErr)
+		}
+		return
+	}
+
 	if hErr != nil {
 		p.handleJetStreamError(route, msg, meta, hErr)
 		return
 	}
 
+	if route.DLQEnabled() {
+		p.failures.forget(meta)
+	}
+
 	if err := msg.Ack(); err != nil {
 		p.logger.Error("ack error", "subject", route.Subject(), "error", err)
+		p.observer.AckFailed(route, err)
 	}
 }
 
@@ -201,67 +372,264 @@ func (p *Consumer) handleJetStreamError(
 	meta *jetstream.MsgMetadata,
 	err error,
 ) {
-	p.logger.Error("handler error", "subject", route.Subject(), "error", err)
+	p.logHandlerError(route, msg.Subject(), err)
+
+	var terminal *TerminalError
+	isTerminal := errors.As(err, &terminal)
+
+	// Without metadata the message cannot be tracked nor sent to the DLQ, and is
+	// retried as after its first delivery.
+	delivered := 1
+	if meta != nil {
+		delivered = int(meta.NumDelivered)
+	}
+
+	if route.DLQEnabled() && meta != nil {
+		now := time.Now()
+		first := p.failures.record(meta, now)
 
-	if route.DLQEnabled() && int(meta.NumDelivered) >= route.MaxDeliver() {
-		p.publishToDLQ(route, msg, meta, err)
+		if isTerminal || delivered >= route.MaxDeliver() {
+			p.publishToDLQ(route, msg, meta, err, first, now)
+			return
+		}
+	}
+
+	if isTerminal {
+		if termErr := msg.Term(); termErr != nil {
+			p.logger.Error("term error", "subject", route.Subject(), "error", termErr)
+			p.observer.AckFailed(route, termErr)
+		}
 		return
 	}
 
-	if nakErr := msg.Nak(); nakErr != nil {
-		p.logger.Error("nak error", "subject", route.Subject(), "error", nakErr)
+	var retry *RetryError
+	if errors.As(err, &retry) {
+		p.nak(route, msg, retry.Delay)
+		return
+	}
+
+	p.nak(route, msg, route.BackoffDelay(delivered))
+}
+
+func (p *Consumer) nak(route *router.Route, msg jetstream.Msg, delay time.Duration) {
+	var err error
+	if delay > 0 {
+		err = msg.NakWithDelay(delay)
+	} else {
+		err = msg.Nak()
+	}
+
+	if err != nil {
+		p.logger.Error("nak error", "subject", route.Subject(), "error", err)
+		p.observer.AckFailed(route, err)
 	}
 }
 
+// publishToDLQ publishes a failed message to the route DLQ subject through JetStream
+// and acknowledges the original only once the DLQ stream confirmed the write.
+// If the publish fails, the original is negatively acknowledged instead, unless this
+// was its last delivery: JetStream would not redeliver it, so the publish is retried
+// while the ack deadline is extended, and the original is left unacknowledged if it
+// still fails.
 func (p *Consumer) publishToDLQ(
 	route *router.Route,
 	msg jetstream.Msg,
 	meta *jetstream.MsgMetadata,
 	err error,
+	firstFailure time.Time,
+	lastFailure time.Time,
 ) {
-	const dlqPRefix = "dlq."
-	headers := nats.Header{}
-	headers.Set(HeaderErrorKey, err.Error())
-	headers.Set(HeaderRetryCountKey, strconv.Itoa(int(meta.NumDelivered)))
-
-	dlqSubject := dlqPRefix + route.Subject()
+	dlqSubject := route.DLQSubject(msg.Subject())
 
-	if pubErr := p.nc.PublishMsg(&nats.Msg{
+	out := &nats.Msg{
 		Subject: dlqSubject,
-		Header:  headers,
+		Header:  dlqHeaders(msg, meta, err, firstFailure, lastFailure),
 		Data:    msg.Data(),
-	}); pubErr != nil {
+	}
+
+	// The message ID makes a second publish of the same failure a duplicate in the DLQ stream.
+	msgID := meta.Stream + ":" + meta.Consumer + ":" + strconv.FormatUint(meta.Sequence.Stream, 10)
+
+	lastDelivery := route.MaxDeliver() > 0 && int(meta.NumDelivered) >= route.MaxDeliver()
+
+	attempts := 1
+	if lastDelivery {
+		attempts = dlqPublishAttempts
+	}
+
+	p.failures.forget(meta)
+
+	if pubErr := p.publishDLQMsg(msg, out, msgID, attempts); pubErr != nil {
+		p.observer.DLQPublishFailed(route, pubErr)
+
+		if lastDelivery {
+			p.logger.Error(
+				"dlq publish failed on the last delivery, message left unacknowledged",
+				"subject", route.Subject(),
+				"dlq_subject", dlqSubject,
+				"sequence", meta.Sequence.Stream,
+				"error", pubErr,
+			)
+			return
+		}
+
 		p.logger.Error("dlq publish error", "subject", dlqSubject, "error", pubErr)
-		_ = msg.Nak()
+		p.nak(route, msg, route.BackoffDelay(int(meta.NumDelivered)))
 		return
 	}
 
+	p.observer.DLQPublished(route)
+
 	if ackErr := msg.Ack(); ackErr != nil {
 		p.logger.Error("ack error after dlq", "subject", route.Subject(), "error", ackErr)
+		p.observer.AckFailed(route, ackErr)
 	}
 }
 
-func (p *Consumer) drainOnCancel(ctx context.Context, fn func()) {
+// publishDLQMsg publishes out with up to attempts tries, doubling the delay between
+// them. While waiting, the ack deadline of msg is extended so it is not redelivered.
+func (p *Consumer) publishDLQMsg(msg jetstream.Msg, out *nats.Msg, msgID string, attempts int) error {
+	delay := dlqRetryDelay
+
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		// The publish must survive the consumer context being canceled during shutdown.
+		if _, err = p.js.PublishMsg(context.Background(), out, jetstream.WithMsgID(msgID)); err == nil {
+			return nil
+		}
+
+		if attempt >= attempts {
+			return err
+		}
+
+		p.logger.Debug("dlq publish retry", "subject", out.Subject, "attempt", attempt, "error", err)
+
+		if ipErr := msg.InProgress(); ipErr != nil {
+			return errors.Join(err, ipErr)
+		}
+
+		time.Sleep(delay)
+		delay *= dlqRetryFactor
+	}
+}
+
+// dlqHeaders copies the original message headers and adds the failure context.
+// JetStream headers (Nats-*) are dropped so they do not affect the DLQ stream.
+func dlqHeaders(
+	msg jetstream.Msg,
+	meta *jetstream.MsgMetadata,
+	err error,
+	firstFailure time.Time,
+	lastFailure time.Time,
+) nats.Header {
+	headers := nats.Header{}
+
+	for k, values := range msg.Headers() {
+		if strings.HasPrefix(k, "Nats-") {
+			continue
+		}
+		headers[k] = append([]string(nil), values...)
+	}
+
+	headers.Set(HeaderErrorKey, err.Error())
+	headers.Set(HeaderRetryCountKey, strconv.FormatUint(meta.NumDelivered, 10))
+	headers.Set(HeaderOriginalSubjectKey, msg.Subject())
+	headers.Set(HeaderOriginalStreamKey, meta.Stream)
+	headers.Set(HeaderOriginalSequenceKey, strconv.FormatUint(meta.Sequence.Stream, 10))
+	headers.Set(HeaderOriginalConsumerKey, meta.Consumer)
+	headers.Set(HeaderFirstFailureKey, firstFailure.UTC().Format(time.RFC3339Nano))
+	headers.Set(HeaderLastFailureKey, lastFailure.UTC().Format(time.RFC3339Nano))
+
+	return headers
+}
+
+func (p *Consumer) logHandlerError(route *router.Route, subject string, err error) {
+	if errors.Is(err, loafernatsx.ErrHandlerTimeout) {
+		p.logger.Error("handler timeout", "subject", subject, "timeout", route.HandlerTimeout(), "error", err)
+		return
+	}
+
+	p.logger.Error("handler error", "subject", subject, "error", err)
+}
+
+// dispatcherFor returns the worker pool for a route, honoring the route concurrency
+// and ordering settings and falling back to the consumer default.
+func (p *Consumer) dispatcherFor(route *router.Route) *dispatcher {
+	workers := route.Concurrency()
+	if workers == 0 {
+		workers = p.concurrency
+	}
+
+	if route.Ordered() {
+		workers = 1
+	}
+
+	return newDispatcher(workers, route.OrderingKey())
+}
+
+// drainOnCancel registers the route for Wait and runs stop once ctx is canceled.
+// stop must return only after the subscription stopped delivering messages.
+func (p *Consumer) drainOnCancel(ctx context.Context, f *inflight, stop func()) {
+	p.track(f)
+
 	go func() {
 		<-ctx.Done()
-		fn()
+		stop()
+		f.close()
 	}()
 }
 
+// drainSubscription drains sub and returns once it is closed, that is after the
+// messages it already received were delivered or the connection closed. While
+// disconnected the drain cannot complete, so the subscription is dropped instead.
+func (p *Consumer) drainSubscription(sub *nats.Subscription) {
+	if !p.nc.IsConnected() || sub.Drain() != nil {
+		_ = sub.Unsubscribe()
+	}
+
+	t := time.NewTicker(drainPollInterval)
+	defer t.Stop()
+
+	for sub.IsValid() {
+		<-t.C
+	}
+}
+
+// drainConsumer drains consumeCtx and returns once it is closed, that is after the
+// messages it already buffered were handled. While disconnected the drain cannot
+// complete, so the buffered messages are dropped and left to be redelivered.
+func (p *Consumer) drainConsumer(consumeCtx jetstream.ConsumeContext) {
+	if p.nc.IsConnected() {
+		consumeCtx.Drain()
+	} else {
+		consumeCtx.Stop()
+	}
+
+	<-consumeCtx.Closed()
+}
+
+// safeHandle runs fn, recovering its panics, and returns once the handlers it
+// started returned, even those abandoned after their timeout, so the caller keeps
+// its worker and in-flight accounting until then.
 func (p *Consumer) safeHandle(
 	ctx context.Context,
-	subject string,
-	fn func(),
+	route *router.Route,
+	fn func(ctx context.Context),
 ) {
+	ctx, wait := withHandlers(ctx)
+	defer wait()
+
 	defer func() {
 		if r := recover(); r != nil {
 			p.logger.Error(
 				"handler panic recovered",
-				"subject", subject,
+				"subject", route.Subject(),
 				"panic", r,
 			)
+			p.observer.PanicRecovered(route, r)
 		}
 	}()
 
-	fn()
+	fn(ctx)
 }
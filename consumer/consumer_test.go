@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,9 +13,13 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/conn"
 	"github.com/silviolleite/loafer-natsx/consumer"
 	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/reply"
 	"github.com/silviolleite/loafer-natsx/router"
 )
 
@@ -454,3 +459,461 @@ func TestDrainOnCancel(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestStartMessage_PubSub_WildcardSubjectAndHeaders(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypePubSub, "test.msg.*")
+
+	received := make(chan consumer.Message, 1)
+
+	err := c.StartMessage(ctx, r, func(ctx context.Context, msg consumer.Message) (any, error) {
+		received <- msg
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	out := &nats.Msg{Subject: "test.msg.created", Data: []byte("data"), Header: nats.Header{}}
+	out.Header.Set(consumer.HeaderCorrelationIDKey, "cid-1")
+	_ = nc.PublishMsg(out)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "test.msg.created", msg.Subject())
+		assert.Equal(t, []byte("data"), msg.Data())
+		assert.Equal(t, "cid-1", msg.Headers().Get(consumer.HeaderCorrelationIDKey))
+
+		meta, mErr := msg.Metadata()
+		assert.Nil(t, meta)
+		assert.ErrorIs(t, mErr, loafernatsx.ErrNotJetStreamMessage)
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestStartMessage_JetStream_Metadata(t *testing.T) {
+	s, url := runServer(true)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	js, _ := jetstream.New(nc)
+
+	_, _ = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "TESTMETA",
+		Subjects: []string{"test.meta"},
+	})
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.meta",
+		router.WithStream("TESTMETA"),
+		router.WithDurable("dmeta"),
+	)
+
+	received := make(chan *jetstream.MsgMetadata, 1)
+
+	err := c.StartMessage(ctx, r, func(ctx context.Context, msg consumer.Message) (any, error) {
+		meta, mErr := msg.Metadata()
+		if mErr == nil {
+			received <- meta
+		}
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	_, _ = js.Publish(context.Background(), "test.meta", []byte("data"))
+
+	select {
+	case meta := <-received:
+		assert.Equal(t, "TESTMETA", meta.Stream)
+		assert.Equal(t, "dmeta", meta.Consumer)
+		assert.Greater(t, meta.Sequence.Stream, uint64(0))
+		assert.Equal(t, uint64(1), meta.NumDelivered)
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestHandlerTimeout_RequestReply_DefaultReply(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeRequestReply,
+		"test.req.timeout",
+		router.WithQueueGroup("workers"),
+		router.WithHandlerTimeout(50*time.Millisecond),
+	)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.NoError(t, err)
+
+	start := time.Now()
+	msg, err := nc.Request("test.req.timeout", []byte("data"), 2*time.Second)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, string(reply.StatusError), msg.Header.Get(reply.HeaderStatus))
+	assert.Equal(t, loafernatsx.ErrHandlerTimeout.Error(), string(msg.Data))
+}
+
+func TestHandlerTimeout_RequestReply_CustomReply(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeRequestReply,
+		"test.req.timeout.custom",
+		router.WithQueueGroup("workers"),
+		router.WithHandlerTimeout(50*time.Millisecond),
+		router.WithReply(reply.JSON),
+	)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		<-release
+		return "late", nil
+	})
+	assert.NoError(t, err)
+
+	msg, err := nc.Request("test.req.timeout.custom", []byte("data"), 2*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, string(reply.StatusError), msg.Header.Get(reply.HeaderStatus))
+	assert.Contains(t, string(msg.Data), loafernatsx.ErrHandlerTimeout.Error())
+}
+
+func TestHandlerTimeout_JetStream_Nak(t *testing.T) {
+	s, url := runServer(true)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	js, _ := jetstream.New(nc)
+
+	_, _ = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "TESTTIMEOUT",
+		Subjects: []string{"test.timeout"},
+	})
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.timeout",
+		router.WithStream("TESTTIMEOUT"),
+		router.WithDurable("dtimeout"),
+		router.WithHandlerTimeout(50*time.Millisecond),
+	)
+
+	redelivered := make(chan uint64, 1)
+
+	err := c.StartMessage(ctx, r, func(ctx context.Context, msg consumer.Message) (any, error) {
+		meta, _ := msg.Metadata()
+		if meta.NumDelivered == 1 {
+			<-ctx.Done()
+			return nil, nil
+		}
+
+		redelivered <- meta.NumDelivered
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	_, _ = js.Publish(context.Background(), "test.timeout", []byte("data"))
+
+	// The default AckWait is 30s, so a redelivery within a few seconds proves the timeout Nak'ed the message.
+	select {
+	case n := <-redelivered:
+		assert.Equal(t, uint64(2), n)
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not redelivered after handler timeout")
+	}
+}
+
+func TestHandlerTimeout_KeepsWorkerUntilHandlerReturns(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypePubSub,
+		"test.timeout.ordered",
+		router.WithOrdered(),
+		router.WithHandlerTimeout(50*time.Millisecond),
+	)
+
+	started := make(chan string, 2)
+	release := make(chan struct{})
+
+	err := c.Start(ctx, r, func(_ context.Context, b []byte) (any, error) {
+		started <- string(b)
+		if string(b) == "first" {
+			// Ignore the context so the handler outlives its timeout.
+			<-release
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_ = nc.Publish("test.timeout.ordered", []byte("first"))
+	_ = nc.Publish("test.timeout.ordered", []byte("second"))
+
+	assert.Equal(t, "first", <-started)
+
+	select {
+	case <-started:
+		t.Fatal("next message handled while the timed out handler was still running")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+
+	wctx, wcancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer wcancel()
+	assert.ErrorIs(t, c.Wait(wctx), loafernatsx.ErrShutdownTimeout)
+
+	close(release)
+
+	select {
+	case b := <-started:
+		assert.Equal(t, "second", b)
+	case <-time.After(2 * time.Second):
+		t.Fatal("next message not handled after the timed out handler returned")
+	}
+
+	assert.NoError(t, c.Wait(context.Background()))
+}
+
+// countingJetStream counts the consumers created through it.
+type countingJetStream struct {
+	jetstream.JetStream
+	created atomic.Int32
+}
+
+func (c *countingJetStream) CreateOrUpdateConsumer(
+	ctx context.Context,
+	stream string,
+	cfg jetstream.ConsumerConfig,
+) (jetstream.Consumer, error) {
+	c.created.Add(1)
+	return c.JetStream.CreateOrUpdateConsumer(ctx, stream, cfg)
+}
+
+func TestWithJetStream(t *testing.T) {
+	s, url := runServer(true)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	inner, _ := jetstream.New(nc)
+
+	_, err := inner.CreateOrUpdateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "SHARED",
+		Subjects: []string{"shared.js"},
+	})
+	require.NoError(t, err)
+
+	js := &countingJetStream{JetStream: inner}
+
+	c, err := consumer.New(nc, logger.NopLogger{}, consumer.WithJetStream(js))
+	require.NoError(t, err)
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"shared.js",
+		router.WithStream("SHARED"),
+		router.WithDurable("shared"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan struct{}, 1)
+
+	err = c.Start(ctx, r, func(context.Context, []byte) (any, error) {
+		received <- struct{}{}
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), js.created.Load())
+
+	_, err = inner.Publish(context.Background(), "shared.js", []byte("data"))
+	require.NoError(t, err)
+
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestWithConnection(t *testing.T) {
+	s, url := runServer(true)
+	defer s.Shutdown()
+
+	c, err := conn.Open(url)
+	require.NoError(t, err)
+	defer func() { _ = c.Close(context.Background()) }()
+
+	_, err = c.JetStream().CreateOrUpdateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "CONN",
+		Subjects: []string{"conn.js"},
+	})
+	require.NoError(t, err)
+
+	cons, err := consumer.New(nil, logger.NopLogger{}, consumer.WithConnection(c))
+	require.NoError(t, err)
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"conn.js",
+		router.WithStream("CONN"),
+		router.WithDurable("conn"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan struct{}, 1)
+
+	err = cons.Start(ctx, r, func(context.Context, []byte) (any, error) {
+		received <- struct{}{}
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_, err = c.JetStream().Publish(context.Background(), "conn.js", []byte("data"))
+	require.NoError(t, err)
+
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestJetStream_ConsumerConfig(t *testing.T) {
+	s, url := runServer(true)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	js, _ := jetstream.New(nc)
+
+	_, err := js.CreateOrUpdateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "CONSUMERCFG",
+		Subjects: []string{"consumercfg.>"},
+	})
+	require.NoError(t, err)
+
+	c, err := consumer.New(nc, logger.NopLogger{})
+	require.NoError(t, err)
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"consumercfg.created",
+		router.WithStream("CONSUMERCFG"),
+		router.WithDurable("consumercfg"),
+		router.WithFilterSubjects("consumercfg.updated"),
+		router.WithMaxAckPending(50),
+		router.WithInactiveThreshold(time.Hour),
+		router.WithHeadersOnly(),
+		router.WithSampleFrequency(25),
+		router.WithMaxRequestBatch(500),
+		router.WithReplicas(1),
+		router.WithMemoryStorage(),
+		router.WithMetadata(map[string]string{"team": "orders"}),
+		router.WithDescription("consumer config"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan consumer.Message, 2)
+
+	err = c.StartMessage(ctx, r, func(_ context.Context, msg consumer.Message) (any, error) {
+		received <- msg
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	cons, err := js.Consumer(context.Background(), "CONSUMERCFG", "consumercfg")
+	require.NoError(t, err)
+
+	cfg := cons.CachedInfo().Config
+	assert.Empty(t, cfg.FilterSubject)
+	assert.Equal(t, []string{"consumercfg.created", "consumercfg.updated"}, cfg.FilterSubjects)
+	assert.Equal(t, 50, cfg.MaxAckPending)
+	assert.Equal(t, time.Hour, cfg.InactiveThreshold)
+	assert.True(t, cfg.HeadersOnly)
+	assert.Equal(t, "25%", cfg.SampleFrequency)
+	assert.Equal(t, 500, cfg.MaxRequestBatch)
+	assert.Equal(t, 1, cfg.Replicas)
+	assert.True(t, cfg.MemoryStorage)
+	assert.Equal(t, "orders", cfg.Metadata["team"])
+	assert.Equal(t, "consumer config", cfg.Description)
+
+	for _, subject := range []string{"consumercfg.created", "consumercfg.updated"} {
+		_, err = js.Publish(context.Background(), subject, []byte("payload"))
+		require.NoError(t, err)
+	}
+
+	for range 2 {
+		select {
+		case msg := <-received:
+			assert.Empty(t, msg.Data(), "headers only")
+			assert.Equal(t, "7", msg.Headers().Get("Nats-Msg-Size"))
+		case <-time.After(2 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package consumer
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// maxPendingPerLane bounds how many messages a keyed dispatcher holds per lane
+// before it blocks the subscription.
+const maxPendingPerLane = 64
+
+// dispatcher hands messages received by a single subscription to a bounded
+// number of goroutines. With one worker it runs handlers inline on the
+// subscription goroutine, which preserves delivery order. With an ordering
+// key, messages are spread over one lane per worker by key hash, and every
+// lane runs its messages sequentially.
+type dispatcher struct {
+	sem   chan struct{}
+	key   router.KeyFunc
+	lanes []*lane
+}
+
+// lane is a FIFO queue drained by at most one goroutine at a time.
+type lane struct {
+	queue   []func()
+	mu      sync.Mutex
+	running bool
+}
+
+func newDispatcher(workers int, key router.KeyFunc) *dispatcher {
+	if workers <= 1 {
+		return &dispatcher{}
+	}
+
+	if key == nil {
+		return &dispatcher{sem: make(chan struct{}, workers)}
+	}
+
+	lanes := make([]*lane, workers)
+	for i := range lanes {
+		lanes[i] = &lane{}
+	}
+
+	return &dispatcher{
+		sem:   make(chan struct{}, workers*maxPendingPerLane),
+		key:   key,
+		lanes: lanes,
+	}
+}
+
+// dispatch runs fn on a free worker, blocking the caller while the dispatcher is
+// saturated so the subscription applies backpressure instead of buffering without bound.
+func (d *dispatcher) dispatch(subject string, headers nats.Header, fn func()) {
+	if d.sem == nil {
+		fn()
+		return
+	}
+
+	d.sem <- struct{}{}
+
+	if d.key != nil {
+		d.enqueue(d.laneFor(subject, headers), fn)
+		return
+	}
+
+	go func() {
+		defer d.release()
+		fn()
+	}()
+}
+
+func (d *dispatcher) laneFor(subject string, headers nats.Header) *lane {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(d.key(subject, headers)))
+	return d.lanes[h.Sum32()%uint32(len(d.lanes))]
+}
+
+func (d *dispatcher) enqueue(l *lane, fn func()) {
+	l.mu.Lock()
+	l.queue = append(l.queue, fn)
+	if l.running {
+		l.mu.Unlock()
+		return
+	}
+	l.running = true
+	l.mu.Unlock()
+
+	go d.drainLane(l)
+}
+
+// drainLane runs queued functions in order until the lane is empty.
+func (d *dispatcher) drainLane(l *lane) {
+	for {
+		l.mu.Lock()
+		if len(l.queue) == 0 {
+			l.running = false
+			l.mu.Unlock()
+			return
+		}
+
+		fn := l.queue[0]
+		l.queue[0] = nil
+		l.queue = l.queue[1:]
+		l.mu.Unlock()
+
+		fn()
+		<-d.sem
+	}
+}
+
+func (d *dispatcher) release() {
+	<-d.sem
+}
@@ -0,0 +1,281 @@
+package consumer_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func TestConcurrency_RouteProcessesInParallel(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypePubSub, "test.concurrency", router.WithConcurrency(3))
+	assert.Equal(t, 3, r.Concurrency())
+
+	var (
+		active    atomic.Int32
+		maxActive atomic.Int32
+		wg        sync.WaitGroup
+	)
+
+	release := make(chan struct{})
+	wg.Add(3)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		n := active.Add(1)
+		for {
+			cur := maxActive.Load()
+			if n <= cur || maxActive.CompareAndSwap(cur, n) {
+				break
+			}
+		}
+
+		wg.Done()
+		<-release
+		active.Add(-1)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_ = nc.Publish("test.concurrency", []byte("data"))
+	}
+
+	wait(&wg)
+	close(release)
+
+	assert.Equal(t, int32(3), maxActive.Load())
+}
+
+func TestConcurrency_DefaultIsSequentialAndOrdered(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypePubSub, "test.ordered.default")
+
+	const total = 20
+
+	var (
+		mu       sync.Mutex
+		received []string
+		wg       sync.WaitGroup
+	)
+
+	wg.Add(total)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		mu.Lock()
+		received = append(received, string(b))
+		mu.Unlock()
+		wg.Done()
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	expected := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		expected = append(expected, strconv.Itoa(i))
+		_ = nc.Publish("test.ordered.default", []byte(strconv.Itoa(i)))
+	}
+
+	wait(&wg)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, expected, received)
+}
+
+func TestWithConcurrency_ConsumerDefault(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, err := consumer.New(nc, logger.NopLogger{}, consumer.WithConcurrency(2), consumer.WithConcurrency(0))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypeQueue, "test.concurrency.default", router.WithQueueGroup("workers"))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	release := make(chan struct{})
+
+	err = c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		wg.Done()
+		<-release
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	_ = nc.Publish("test.concurrency.default", []byte("1"))
+	_ = nc.Publish("test.concurrency.default", []byte("2"))
+
+	// Both messages are in flight at once, which requires two workers.
+	wait(&wg)
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestOrdered_OverridesConcurrency(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{}, consumer.WithConcurrency(4))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypePubSub, "test.ordered", router.WithOrdered())
+	assert.True(t, r.Ordered())
+
+	const total = 10
+
+	var (
+		active    atomic.Int32
+		maxActive atomic.Int32
+		mu        sync.Mutex
+		received  []string
+		wg        sync.WaitGroup
+	)
+
+	wg.Add(total)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		if n := active.Add(1); n > maxActive.Load() {
+			maxActive.Store(n)
+		}
+		time.Sleep(2 * time.Millisecond)
+
+		mu.Lock()
+		received = append(received, string(b))
+		mu.Unlock()
+
+		active.Add(-1)
+		wg.Done()
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	expected := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		expected = append(expected, strconv.Itoa(i))
+		_ = nc.Publish("test.ordered", []byte(strconv.Itoa(i)))
+	}
+
+	wait(&wg)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, expected, received)
+	assert.Equal(t, int32(1), maxActive.Load())
+}
+
+func TestOrderingKey_SerializesPerKeyAndParallelizesAcrossKeys(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypePubSub,
+		"test.keyed.*",
+		router.WithConcurrency(4),
+		router.WithOrderingKey(router.KeyFromSubjectToken(-1)),
+	)
+
+	const perKey = 10
+	keys := []string{"a", "b", "c"}
+
+	var (
+		active    atomic.Int32
+		maxActive atomic.Int32
+		mu        sync.Mutex
+		received  = map[string][]string{}
+		wg        sync.WaitGroup
+	)
+
+	wg.Add(perKey * len(keys))
+
+	err := c.StartMessage(ctx, r, func(ctx context.Context, msg consumer.Message) (any, error) {
+		n := active.Add(1)
+		for {
+			cur := maxActive.Load()
+			if n <= cur || maxActive.CompareAndSwap(cur, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		received[msg.Subject()] = append(received[msg.Subject()], string(msg.Data()))
+		mu.Unlock()
+
+		active.Add(-1)
+		wg.Done()
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < perKey; i++ {
+		for _, k := range keys {
+			_ = nc.Publish("test.keyed."+k, []byte(strconv.Itoa(i)))
+		}
+	}
+
+	wait(&wg)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	expected := make([]string, 0, perKey)
+	for i := 0; i < perKey; i++ {
+		expected = append(expected, strconv.Itoa(i))
+	}
+
+	for _, k := range keys {
+		assert.Equal(t, expected, received["test.keyed."+k], "key %s out of order", k)
+	}
+
+	assert.Greater(t, maxActive.Load(), int32(1))
+}
@@ -0,0 +1,181 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func TestJetStream_DLQ_HeadersAndCustomSubject(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTDLQHDR", "test.dlqhdr.*")
+	createStream(t, js, "TESTDLQHDR_FAILED", "failed.>")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.dlqhdr.*",
+		router.WithStream("TESTDLQHDR"),
+		router.WithDurable("ddlqhdr"),
+		router.WithMaxDeliver(2),
+		router.WithDLQSubjectFunc(func(subject string) string {
+			return "failed." + subject
+		}),
+	)
+
+	dlq := make(chan *nats.Msg, 1)
+	_, _ = nc.Subscribe("failed.test.dlqhdr.eu", func(msg *nats.Msg) {
+		dlq <- msg
+	})
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	ack, err := js.PublishMsg(context.Background(), &nats.Msg{
+		Subject: "test.dlqhdr.eu",
+		Data:    []byte("data"),
+		Header:  nats.Header{"X-Tenant": []string{"acme"}, "Nats-Msg-Id": []string{"original-id"}},
+	})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-dlq:
+		assert.Equal(t, "data", string(msg.Data))
+		assert.Equal(t, "boom", msg.Header.Get(consumer.HeaderErrorKey))
+		assert.Equal(t, "2", msg.Header.Get(consumer.HeaderRetryCountKey))
+		assert.Equal(t, "test.dlqhdr.eu", msg.Header.Get(consumer.HeaderOriginalSubjectKey))
+		assert.Equal(t, "TESTDLQHDR", msg.Header.Get(consumer.HeaderOriginalStreamKey))
+		assert.Equal(t, strconv.FormatUint(ack.Sequence, 10), msg.Header.Get(consumer.HeaderOriginalSequenceKey))
+		assert.Equal(t, "ddlqhdr", msg.Header.Get(consumer.HeaderOriginalConsumerKey))
+		assert.Equal(t, "acme", msg.Header.Get("X-Tenant"))
+		assert.NotEqual(t, "original-id", msg.Header.Get("Nats-Msg-Id"))
+
+		first, fErr := time.Parse(time.RFC3339Nano, msg.Header.Get(consumer.HeaderFirstFailureKey))
+		require.NoError(t, fErr)
+		last, lErr := time.Parse(time.RFC3339Nano, msg.Header.Get(consumer.HeaderLastFailureKey))
+		require.NoError(t, lErr)
+		assert.True(t, first.Before(last))
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not published to DLQ")
+	}
+
+	stored, err := js.Stream(context.Background(), "TESTDLQHDR_FAILED")
+	require.NoError(t, err)
+	info, err := stored.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs)
+}
+
+func TestJetStream_DLQ_MissingStreamKeepsSource(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTDLQMISSING", "test.dlqmissing")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.dlqmissing",
+		router.WithStream("TESTDLQMISSING"),
+		router.WithDurable("ddlqmissing"),
+		router.WithMaxDeliver(1),
+		router.WithDLQSubject("nowhere.dlqmissing"),
+	)
+
+	handled := make(chan struct{}, 1)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		handled <- struct{}{}
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	_, err = js.Publish(context.Background(), "test.dlqmissing", []byte("data"))
+	require.NoError(t, err)
+
+	select {
+	case <-handled:
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not handled")
+	}
+
+	cons, err := js.Consumer(context.Background(), "TESTDLQMISSING", "ddlqmissing")
+	require.NoError(t, err)
+
+	// The DLQ publish has no stream to land in, so the source must not be acknowledged.
+	assert.Never(t, func() bool {
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.AckFloor.Stream > 0
+	}, 500*time.Millisecond, 50*time.Millisecond)
+}
+
+func TestJetStream_DLQ_RetriesPublishOnLastDelivery(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTDLQRETRY", "test.dlqretry")
+	_ = js.DeleteStream(context.Background(), "TESTDLQRETRY_DLQ")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.dlqretry",
+		router.WithStream("TESTDLQRETRY"),
+		router.WithDurable("ddlqretry"),
+		router.WithMaxDeliver(1),
+		router.WithDLQSubject("dlq.dlqretry"),
+	)
+
+	handled := make(chan struct{}, 1)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		handled <- struct{}{}
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	_, err = js.Publish(context.Background(), "test.dlqretry", []byte("data"))
+	require.NoError(t, err)
+
+	select {
+	case <-handled:
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not handled")
+	}
+
+	// The first publish fails as the DLQ stream does not exist yet; it is retried
+	// instead of losing the message on its last delivery.
+	time.Sleep(time.Second)
+	createStream(t, js, "TESTDLQRETRY_DLQ", "dlq.dlqretry")
+
+	cons, err := js.Consumer(context.Background(), "TESTDLQRETRY", "ddlqretry")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.AckFloor.Stream == 1 && info.NumAckPending == 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	stored, err := js.Stream(context.Background(), "TESTDLQRETRY_DLQ")
+	require.NoError(t, err)
+	info, err := stored.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs)
+}
@@ -0,0 +1,97 @@
+package consumer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// failureTTL is how long the first failure of a message is remembered. Entries
+	// left behind, for instance when another instance handled the redelivery, expire.
+	failureTTL = 24 * time.Hour
+
+	// maxTrackedFailures bounds the number of remembered messages; the oldest entry is
+	// evicted to make room for a new one.
+	maxTrackedFailures = 10000
+)
+
+type failureKey struct {
+	stream   string
+	consumer string
+	sequence uint64
+}
+
+// failureTracker remembers when a JetStream message first failed, so the DLQ can
+// report the whole failure window. Only failures observed by this process are known,
+// for at most failureTTL and maxTrackedFailures messages.
+type failureTracker struct {
+	first map[failureKey]time.Time
+	mu    sync.Mutex
+}
+
+func newFailureTracker() *failureTracker {
+	return &failureTracker{first: make(map[failureKey]time.Time)}
+}
+
+func failureKeyOf(meta *jetstream.MsgMetadata) failureKey {
+	return failureKey{
+		stream:   meta.Stream,
+		consumer: meta.Consumer,
+		sequence: meta.Sequence.Stream,
+	}
+}
+
+// record registers a failure at now and returns the time of the first known failure.
+func (t *failureTracker) record(meta *jetstream.MsgMetadata, now time.Time) time.Time {
+	key := failureKeyOf(meta)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if first, ok := t.first[key]; ok && now.Sub(first) < failureTTL {
+		return first
+	}
+
+	if len(t.first) >= maxTrackedFailures {
+		t.evict(now)
+	}
+
+	t.first[key] = now
+	return now
+}
+
+// evict drops the expired entries, or the oldest one when none expired.
+// It must be called with mu held.
+func (t *failureTracker) evict(now time.Time) {
+	var (
+		oldestKey failureKey
+		oldest    time.Time
+	)
+
+	for key, first := range t.first {
+		if now.Sub(first) >= failureTTL {
+			delete(t.first, key)
+			continue
+		}
+		if oldest.IsZero() || first.Before(oldest) {
+			oldestKey, oldest = key, first
+		}
+	}
+
+	if len(t.first) >= maxTrackedFailures {
+		delete(t.first, oldestKey)
+	}
+}
+
+// forget drops the failure history of a message that reached a final state.
+func (t *failureTracker) forget(meta *jetstream.MsgMetadata) {
+	if meta == nil {
+		return
+	}
+
+	t.mu.Lock()
+	delete(t.first, failureKeyOf(meta))
+	t.mu.Unlock()
+}
@@ -4,3 +4,20 @@ import "context"
 
 // HandlerFunc defines the function signature for message processing.
 type HandlerFunc func(ctx context.Context, data []byte) (any, error)
+
+// MessageHandlerFunc defines the function signature for message-aware processing.
+// It receives the full Message, giving access to headers, the concrete subject,
+// the reply subject and JetStream metadata.
+type MessageHandlerFunc func(ctx context.Context, msg Message) (any, error)
+
+// AdaptHandler converts a byte-only HandlerFunc into a MessageHandlerFunc that
+// invokes h with the message payload. A nil h yields a nil MessageHandlerFunc.
+func AdaptHandler(h HandlerFunc) MessageHandlerFunc {
+	if h == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, msg Message) (any, error) {
+		return h(ctx, msg.Data())
+	}
+}
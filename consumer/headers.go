@@ -10,6 +10,24 @@ const (
 	// HeaderRetryCountKey is the name of the header used to specify the count of retry attempts for a request.
 	HeaderRetryCountKey = "X-Retry-Count"
 
+	// HeaderOriginalSubjectKey is the name of the DLQ header carrying the subject the failed message was published to.
+	HeaderOriginalSubjectKey = "X-Original-Subject"
+
+	// HeaderOriginalStreamKey is the name of the DLQ header carrying the stream the failed message was stored in.
+	HeaderOriginalStreamKey = "X-Original-Stream"
+
+	// HeaderOriginalSequenceKey is the name of the DLQ header carrying the stream sequence of the failed message.
+	HeaderOriginalSequenceKey = "X-Original-Sequence"
+
+	// HeaderOriginalConsumerKey is the name of the DLQ header carrying the JetStream consumer that failed the message.
+	HeaderOriginalConsumerKey = "X-Original-Consumer"
+
+	// HeaderFirstFailureKey is the name of the DLQ header carrying the time of the first failed attempt (RFC 3339).
+	HeaderFirstFailureKey = "X-First-Failure"
+
+	// HeaderLastFailureKey is the name of the DLQ header carrying the time of the last failed attempt (RFC 3339).
+	HeaderLastFailureKey = "X-Last-Failure"
+
 	// HeaderCorrelationIDKey is the name of the header used to store a unique identifier for tracing a request across services.
 	HeaderCorrelationIDKey = "X-Correlation-ID"
 
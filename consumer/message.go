@@ -0,0 +1,127 @@
+package consumer
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+)
+
+// Message is a transport-agnostic view of a message delivered to a handler.
+// It abstracts over Core NATS (*nats.Msg) and JetStream (jetstream.Msg) messages
+// so the same handler can read headers, the concrete subject and delivery
+// metadata regardless of the route type.
+type Message interface {
+	// Subject returns the concrete subject the message was published to,
+	// which may differ from the route subject when wildcards are used.
+	Subject() string
+
+	// Reply returns the reply subject, or an empty string when none was set.
+	Reply() string
+
+	// Data returns the message payload.
+	Data() []byte
+
+	// Headers returns the message headers. It may be nil when the message has no headers.
+	Headers() nats.Header
+
+	// Metadata returns the JetStream delivery metadata such as stream sequence and NumDelivered.
+	// Messages received through Core NATS routes return loafernatsx.ErrNotJetStreamMessage.
+	Metadata() (*jetstream.MsgMetadata, error)
+}
+
+// NewMessage wraps a Core NATS message into a Message.
+func NewMessage(msg *nats.Msg) Message {
+	return &coreMessage{msg: msg}
+}
+
+// NewJetStreamMessage wraps a JetStream message into a Message.
+func NewJetStreamMessage(msg jetstream.Msg) Message {
+	return &jetStreamMessage{msg: msg}
+}
+
+type coreMessage struct {
+	msg *nats.Msg
+}
+
+func (m *coreMessage) Subject() string {
+	return m.msg.Subject
+}
+
+func (m *coreMessage) Reply() string {
+	return m.msg.Reply
+}
+
+func (m *coreMessage) Data() []byte {
+	return m.msg.Data
+}
+
+func (m *coreMessage) Headers() nats.Header {
+	return m.msg.Header
+}
+
+func (m *coreMessage) Metadata() (*jetstream.MsgMetadata, error) {
+	return nil, loafernatsx.ErrNotJetStreamMessage
+}
+
+type jetStreamMessage struct {
+	msg     jetstream.Msg
+	settled atomic.Bool
+}
+
+func (m *jetStreamMessage) Subject() string {
+	return m.msg.Subject()
+}
+
+func (m *jetStreamMessage) Reply() string {
+	return m.msg.Reply()
+}
+
+func (m *jetStreamMessage) Data() []byte {
+	return m.msg.Data()
+}
+
+func (m *jetStreamMessage) Headers() nats.Header {
+	return m.msg.Headers()
+}
+
+func (m *jetStreamMessage) Metadata() (*jetstream.MsgMetadata, error) {
+	return m.msg.Metadata()
+}
+
+func (m *jetStreamMessage) Ack() error {
+	m.settled.Store(true)
+	return m.msg.Ack()
+}
+
+func (m *jetStreamMessage) Nak() error {
+	m.settled.Store(true)
+	return m.msg.Nak()
+}
+
+func (m *jetStreamMessage) NakWithDelay(delay time.Duration) error {
+	m.settled.Store(true)
+	return m.msg.NakWithDelay(delay)
+}
+
+func (m *jetStreamMessage) Term() error {
+	m.settled.Store(true)
+	return m.msg.Term()
+}
+
+func (m *jetStreamMessage) TermWithReason(reason string) error {
+	m.settled.Store(true)
+	return m.msg.TermWithReason(reason)
+}
+
+func (m *jetStreamMessage) InProgress() error {
+	return m.msg.InProgress()
+}
+
+// isSettled reports whether the handler already acknowledged the message explicitly.
+func (m *jetStreamMessage) isSettled() bool {
+	return m.settled.Load()
+}
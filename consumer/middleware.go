@@ -0,0 +1,72 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// Middleware wraps a MessageHandlerFunc to add cross-cutting behavior such as
+// logging, metrics or tracing around every handler execution.
+type Middleware func(next MessageHandlerFunc) MessageHandlerFunc
+
+// Tracer starts a span for a handler execution. Start returns the context passed
+// to the handler and a function that ends the span with the handler error.
+type Tracer interface {
+	Start(ctx context.Context, msg Message) (context.Context, func(err error))
+}
+
+// Chain wraps h with mws. The first middleware is the outermost one: it runs first
+// before the handler and last after it. Nil middlewares are skipped.
+func Chain(h MessageHandlerFunc, mws ...Middleware) MessageHandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] != nil {
+			h = mws[i](h)
+		}
+	}
+	return h
+}
+
+// middlewares returns the configured middlewares, preceded by the tracing middleware
+// when a tracer is configured.
+func middlewares(cfg *config) []Middleware {
+	if cfg.tracer == nil {
+		return cfg.middlewares
+	}
+
+	return append([]Middleware{TracingMiddleware(cfg.tracer)}, cfg.middlewares...)
+}
+
+// TracingMiddleware wraps every handler execution in a span started by tracer, as
+// WithTracer does. The handler receives the context returned by the tracer, so spans
+// it creates become children of the consumer span. A nil tracer disables it.
+func TracingMiddleware(tracer Tracer) Middleware {
+	return func(next MessageHandlerFunc) MessageHandlerFunc {
+		if tracer == nil {
+			return next
+		}
+
+		return func(ctx context.Context, msg Message) (any, error) {
+			ctx, end := tracer.Start(ctx, msg)
+
+			res, err := next(ctx, msg)
+
+			end(err)
+
+			return res, err
+		}
+	}
+}
+
+type routeContextKey struct{}
+
+// RouteFromContext returns the route whose handler is running, which lets
+// middlewares label their output with the route instead of the concrete subject.
+func RouteFromContext(ctx context.Context) (*router.Route, bool) {
+	r, ok := ctx.Value(routeContextKey{}).(*router.Route)
+	return r, ok
+}
+
+func contextWithRoute(ctx context.Context, route *router.Route) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, route)
+}
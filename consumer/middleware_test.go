@@ -0,0 +1,91 @@
+package consumer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func recordingMiddleware(name string, calls *[]string) consumer.Middleware {
+	return func(next consumer.MessageHandlerFunc) consumer.MessageHandlerFunc {
+		return func(ctx context.Context, msg consumer.Message) (any, error) {
+			*calls = append(*calls, name+":before")
+			res, err := next(ctx, msg)
+			*calls = append(*calls, name+":after")
+			return res, err
+		}
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+
+	h := consumer.Chain(
+		func(context.Context, consumer.Message) (any, error) {
+			calls = append(calls, "handler")
+			return "ok", nil
+		},
+		recordingMiddleware("outer", &calls),
+		nil,
+		recordingMiddleware("inner", &calls),
+	)
+
+	res, err := h(context.Background(), consumer.NewMessage(&nats.Msg{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+	assert.Equal(t, []string{"outer:before", "inner:before", "handler", "inner:after", "outer:after"}, calls)
+}
+
+func TestRouteFromContext_Missing(t *testing.T) {
+	r, ok := consumer.RouteFromContext(context.Background())
+	assert.False(t, ok)
+	assert.Nil(t, r)
+}
+
+func TestWithMiddleware_AppliesToHandlers(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	routes := make(chan *router.Route, 1)
+
+	mw := func(next consumer.MessageHandlerFunc) consumer.MessageHandlerFunc {
+		return func(ctx context.Context, msg consumer.Message) (any, error) {
+			r, _ := consumer.RouteFromContext(ctx)
+			routes <- r
+			return next(ctx, msg)
+		}
+	}
+
+	c, _ := consumer.New(nc, logger.NopLogger{}, consumer.WithMiddleware(mw))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypePubSub, "middleware.*")
+
+	err = c.Start(ctx, r, func(context.Context, []byte) (any, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_ = nc.Publish("middleware.test", []byte("data"))
+
+	select {
+	case got := <-routes:
+		assert.Same(t, r, got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("middleware not called")
+	}
+}
@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// Observer is notified of delivery events that happen around the handlers, such as
+// redeliveries, acknowledgement failures and DLQ publishes, so they can be measured.
+// Implementations must be safe for concurrent use and should return quickly.
+// Embed NopObserver to implement only some of the methods.
+type Observer interface {
+	// MessageRedelivered is called before handling a JetStream message delivered more than once.
+	MessageRedelivered(route *router.Route, numDelivered uint64)
+
+	// AckFailed is called when acknowledging, negatively acknowledging or terminating
+	// a JetStream message fails.
+	AckFailed(route *router.Route, err error)
+
+	// DLQPublished is called after a failed message was stored in the DLQ stream.
+	DLQPublished(route *router.Route)
+
+	// DLQPublishFailed is called when a failed message could not be published to the DLQ.
+	DLQPublishFailed(route *router.Route, err error)
+
+	// PanicRecovered is called when a handler panic was recovered by the consumer.
+	PanicRecovered(route *router.Route, v any)
+}
+
+// NopObserver is an Observer that ignores every event.
+type NopObserver struct{}
+
+// MessageRedelivered implements Observer.
+func (NopObserver) MessageRedelivered(*router.Route, uint64) {}
+
+// AckFailed implements Observer.
+func (NopObserver) AckFailed(*router.Route, error) {}
+
+// DLQPublished implements Observer.
+func (NopObserver) DLQPublished(*router.Route) {}
+
+// DLQPublishFailed implements Observer.
+func (NopObserver) DLQPublishFailed(*router.Route, error) {}
+
+// PanicRecovered implements Observer.
+func (NopObserver) PanicRecovered(*router.Route, any) {}
+
+// observeDelivery reports msg to the observer when it is a redelivery.
+func (p *Consumer) observeDelivery(route *router.Route, msg jetstream.Msg) {
+	meta, err := msg.Metadata()
+	if err != nil || meta.NumDelivered <= 1 {
+		return
+	}
+
+	p.observer.MessageRedelivered(route, meta.NumDelivered)
+}
@@ -0,0 +1,118 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+type recordingObserver struct {
+	consumer.NopObserver
+	redelivered atomic.Int32
+	dlq         atomic.Int32
+	dlqFailed   atomic.Int32
+	panics      atomic.Int32
+}
+
+func (o *recordingObserver) MessageRedelivered(*router.Route, uint64) { o.redelivered.Add(1) }
+func (o *recordingObserver) DLQPublished(*router.Route)               { o.dlq.Add(1) }
+func (o *recordingObserver) DLQPublishFailed(*router.Route, error)    { o.dlqFailed.Add(1) }
+func (o *recordingObserver) PanicRecovered(*router.Route, any)        { o.panics.Add(1) }
+
+func TestObserver_RedeliveryAndDLQ(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTOBS", "test.obs")
+	createStream(t, js, "TESTOBS_DLQ", "dlq.test.obs")
+
+	obs := &recordingObserver{}
+	c, _ := consumer.New(nc, logger.NopLogger{}, consumer.WithObserver(obs))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.obs",
+		router.WithStream("TESTOBS"),
+		router.WithDurable("dobs"),
+		router.WithMaxDeliver(2),
+		router.WithEnableDLQ(),
+	)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	_, err = js.Publish(context.Background(), "test.obs", []byte("data"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return obs.dlq.Load() == 1 }, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, int32(1), obs.redelivered.Load())
+	assert.Equal(t, int32(0), obs.dlqFailed.Load())
+}
+
+func TestObserver_DLQPublishFailed(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTOBSFAIL", "test.obsfail")
+
+	obs := &recordingObserver{}
+	c, _ := consumer.New(nc, logger.NopLogger{}, consumer.WithObserver(obs))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.obsfail",
+		router.WithStream("TESTOBSFAIL"),
+		router.WithDurable("dobsfail"),
+		router.WithDLQSubject("nowhere.test.obsfail"),
+	)
+
+	err := c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		return nil, consumer.Terminate(errors.New("poison"))
+	})
+	require.NoError(t, err)
+
+	_, err = js.Publish(context.Background(), "test.obsfail", []byte("data"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return obs.dlqFailed.Load() >= 1 }, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, int32(0), obs.dlq.Load())
+}
+
+func TestObserver_PanicRecovered(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	obs := &recordingObserver{}
+	c, _ := consumer.New(nc, logger.NopLogger{}, consumer.WithObserver(obs))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypePubSub, "test.obspanic")
+
+	err = c.Start(ctx, r, func(ctx context.Context, b []byte) (any, error) {
+		panic("boom")
+	})
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+
+	require.NoError(t, nc.Publish("test.obspanic", []byte("data")))
+
+	assert.Eventually(t, func() bool { return obs.panics.Load() == 1 }, 2*time.Second, 20*time.Millisecond)
+}
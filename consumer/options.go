@@ -0,0 +1,70 @@
+package consumer
+
+import (
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/silviolleite/loafer-natsx/conn"
+)
+
+// Option configures a Consumer during creation.
+type Option func(*config)
+
+// WithConcurrency sets how many messages a route processes in parallel when the
+// route does not define its own concurrency via router.WithConcurrency.
+// The default is 1, which processes messages sequentially in delivery order.
+// Values lower than 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithTracer sets a Tracer that starts a span for every message handled by Start
+// or StartMessage. The span wraps the middlewares registered with WithMiddleware,
+// so the trace context extracted from the message headers reaches all of them.
+// StartBatch starts a span per message too, but does not pass it to the handler.
+func WithTracer(tracer Tracer) Option {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
+
+// WithObserver sets an Observer notified of redeliveries, acknowledgement failures,
+// DLQ publishes and recovered panics.
+func WithObserver(o Observer) Option {
+	return func(c *config) {
+		if o != nil {
+			c.observer = o
+		}
+	}
+}
+
+// WithMiddleware registers middlewares applied to every handler started with
+// Start or StartMessage, in the order given (the first one is the outermost).
+// The route handler timeout covers the whole chain.
+func WithMiddleware(mws ...Middleware) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, mws...)
+	}
+}
+
+// WithJetStream sets the JetStream context used by JetStream routes, such as the one
+// of a conn.Connection, instead of creating one from the connection given to New.
+func WithJetStream(js jetstream.JetStream) Option {
+	return func(c *config) {
+		c.js = js
+	}
+}
+
+// WithConnection uses the NATS connection and the JetStream context of c instead of
+// the connection given to New, which can then be nil.
+func WithConnection(c *conn.Connection) Option {
+	return func(cfg *config) {
+		if c != nil {
+			cfg.nc = c.NATS()
+			cfg.js = c.JetStream()
+		}
+	}
+}
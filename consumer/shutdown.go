@@ -0,0 +1,144 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// drainPollInterval is how often a draining subscription is checked for closure.
+const drainPollInterval = 10 * time.Millisecond
+
+// inflight tracks a started route until its subscription stopped delivering
+// messages and every message it delivered was handled.
+//
+// The route handlers run with ctx, which keeps the values of the Start context but
+// is not canceled with it, so the messages handled while the route drains are not
+// failed right away. Wait cancels it once the route is done or its deadline passed.
+type inflight struct {
+	ctx    context.Context
+	stop   context.Context
+	cancel context.CancelFunc
+	route  *router.Route
+	closed chan struct{}
+	idle   chan struct{}
+	n      atomic.Int64
+	once   sync.Once
+}
+
+func newInflight(ctx context.Context, route *router.Route) *inflight {
+	hctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	return &inflight{
+		ctx:    hctx,
+		stop:   ctx,
+		cancel: cancel,
+		route:  route,
+		closed: make(chan struct{}),
+		idle:   make(chan struct{}, 1),
+	}
+}
+
+// begin registers a message handed to the route.
+func (f *inflight) begin() {
+	f.n.Add(1)
+}
+
+// end registers a handled message and signals waiters once the route is idle.
+func (f *inflight) end() {
+	if f.n.Add(-1) > 0 {
+		return
+	}
+
+	select {
+	case f.idle <- struct{}{}:
+	default:
+	}
+}
+
+// close marks the route subscription as stopped.
+func (f *inflight) close() {
+	f.once.Do(func() { close(f.closed) })
+}
+
+// wait blocks until the subscription stopped and no message is in flight, or ctx is done.
+// When ctx is done first, the handlers of a stopping route have their context canceled.
+func (f *inflight) wait(ctx context.Context) error {
+	err := f.waitIdle(ctx)
+	if err == nil || f.stop.Err() != nil {
+		f.cancel()
+	}
+
+	return err
+}
+
+func (f *inflight) waitIdle(ctx context.Context) error {
+	select {
+	case <-f.closed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for f.n.Load() > 0 {
+		select {
+		case <-f.idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// track registers a started route so Wait accounts for it.
+func (p *Consumer) track(f *inflight) {
+	p.mu.Lock()
+	p.inflight = append(p.inflight, f)
+	p.mu.Unlock()
+}
+
+// Wait blocks until every route started by the consumer stopped receiving messages
+// and finished handling the messages it already received, including their
+// acknowledgements. Routes stop when the context given to Start, StartMessage or
+// StartBatch is canceled. Handlers do not observe that cancellation: the messages
+// in flight and those drained from the subscription are handled with a context
+// that is only canceled once ctx is done. When ctx is done first, Wait returns an
+// error wrapping loafernatsx.ErrShutdownTimeout that names the routes still in flight.
+func (p *Consumer) Wait(ctx context.Context) error {
+	p.mu.Lock()
+	routes := append([]*inflight(nil), p.inflight...)
+	p.mu.Unlock()
+
+	var (
+		mu      sync.Mutex
+		pending []string
+		wg      sync.WaitGroup
+	)
+
+	for _, f := range routes {
+		wg.Go(func() {
+			if f.wait(ctx) != nil {
+				mu.Lock()
+				pending = append(pending, f.route.Label())
+				mu.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	slices.Sort(pending)
+
+	return fmt.Errorf("%w: %s", loafernatsx.ErrShutdownTimeout, strings.Join(pending, ", "))
+}
@@ -0,0 +1,230 @@
+package consumer_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func TestWait_CompletesInFlightHandlers(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypePubSub, "test.wait")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	err := c.Start(ctx, r, func(context.Context, []byte) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_ = nc.Publish("test.wait", []byte("data"))
+	<-started
+
+	cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait(context.Background()) }()
+
+	select {
+	case <-done:
+		t.Fatal("Wait returned while a handler was in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case err = <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait did not return after the handler finished")
+	}
+}
+
+func TestWait_Timeout(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(router.TypeQueue, "test.wait.timeout", router.WithQueueGroup("workers"), router.WithName("slow"))
+	idle, _ := router.New(router.TypePubSub, "test.wait.idle")
+
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+
+	require.NoError(t, c.Start(ctx, r, func(ctx context.Context, _ []byte) (any, error) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return nil, nil
+	}))
+	require.NoError(t, c.Start(ctx, idle, func(context.Context, []byte) (any, error) {
+		return nil, nil
+	}))
+
+	_ = nc.Publish("test.wait.timeout", []byte("data"))
+	<-started
+
+	cancel()
+
+	wctx, wcancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer wcancel()
+
+	err := c.Wait(wctx)
+	assert.ErrorIs(t, err, loafernatsx.ErrShutdownTimeout)
+	assert.EqualError(t, err, loafernatsx.ErrShutdownTimeout.Error()+": slow")
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context not canceled once the wait timed out")
+	}
+}
+
+func TestWait_JetStreamAcksBeforeReturning(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTWAIT", "test.wait.js")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.wait.js",
+		router.WithStream("TESTWAIT"),
+		router.WithDurable("dwait"),
+	)
+
+	started := make(chan struct{})
+
+	err := c.Start(ctx, r, func(context.Context, []byte) (any, error) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_, err = js.Publish(context.Background(), "test.wait.js", []byte("data"))
+	require.NoError(t, err)
+	<-started
+
+	cancel()
+
+	require.NoError(t, c.Wait(context.Background()))
+
+	cons, err := js.Consumer(context.Background(), "TESTWAIT", "dwait")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.NumAckPending == 0 && info.AckFloor.Stream == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWait_JetStreamHandlesBufferedMessages(t *testing.T) {
+	nc, js := setupJetStream(t, "TESTDRAIN", "test.drain.js")
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, _ := router.New(
+		router.TypeJetStream,
+		"test.drain.js",
+		router.WithStream("TESTDRAIN"),
+		router.WithDurable("ddrain"),
+		router.WithOrdered(),
+	)
+
+	const total = 5
+
+	for range total {
+		_, err := js.Publish(context.Background(), "test.drain.js", []byte("data"))
+		require.NoError(t, err)
+	}
+
+	started := make(chan struct{}, total)
+	release := make(chan struct{})
+	var handled atomic.Int32
+
+	err := c.Start(ctx, r, func(ctx context.Context, _ []byte) (any, error) {
+		started <- struct{}{}
+		<-release
+		// A handler honoring its context must still handle the drained messages.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		handled.Add(1)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	<-started
+
+	cons, err := js.Consumer(context.Background(), "TESTDRAIN", "ddrain")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.NumPending == 0
+	}, time.Second, 10*time.Millisecond, "every message is buffered by the client")
+
+	cancel()
+
+	// Give the route time to stop before the handlers run, so the buffered messages
+	// are only handled when the consumer is drained rather than stopped.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	require.NoError(t, c.Wait(context.Background()))
+	assert.Equal(t, int32(total), handled.Load())
+
+	assert.Eventually(t, func() bool {
+		info, iErr := cons.Info(context.Background())
+		return iErr == nil && info.NumAckPending == 0 && info.AckFloor.Stream == total
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWait_NoRoutes(t *testing.T) {
+	s, url := runServer(false)
+	defer s.Shutdown()
+
+	nc, _ := nats.Connect(url)
+	defer nc.Close()
+
+	c, _ := consumer.New(nc, logger.NopLogger{})
+
+	assert.NoError(t, c.Wait(context.Background()))
+}
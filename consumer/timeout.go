@@ -0,0 +1,146 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// handlersKey is the context key of the handlers started by runWithTimeout.
+type handlersKey struct{}
+
+// withHandlers returns a context in which runWithTimeout tracks the handlers it
+// starts, and a function waiting for them to return, including the handlers it
+// stopped waiting for once their timeout passed.
+func withHandlers(ctx context.Context) (context.Context, func()) {
+	wg := &sync.WaitGroup{}
+	return context.WithValue(ctx, handlersKey{}, wg), wg.Wait
+}
+
+type handlerResult struct {
+	result   any
+	err      error
+	panicVal any
+	panicked bool
+}
+
+// invoke runs the handler under the route handler timeout, when configured.
+// The handler receives a context whose deadline cause is loafernatsx.ErrHandlerTimeout.
+// Once the deadline passes, invoke returns ErrHandlerTimeout without waiting for the
+// handler, so the message is replied to or Nak'ed right away. The handler keeps
+// running until it returns, and safeHandle holds the worker until then.
+func invoke(
+	ctx context.Context,
+	route *router.Route,
+	handler MessageHandlerFunc,
+	msg Message,
+) (any, error) {
+	ctx = contextWithRoute(ctx, route)
+
+	return runWithTimeout(ctx, route.HandlerTimeout(), func(ctx context.Context) (any, error) {
+		return handler(ctx, msg)
+	})
+}
+
+// invokeBatch runs a batch handler under the route handler timeout, which covers the whole batch.
+func invokeBatch(
+	ctx context.Context,
+	route *router.Route,
+	handler BatchHandlerFunc,
+	msgs []Message,
+) ([]error, error) {
+	ctx = contextWithRoute(ctx, route)
+
+	res, err := runWithTimeout(ctx, route.HandlerTimeout(), func(ctx context.Context) (any, error) {
+		return handler(ctx, msgs), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results, _ := res.([]error)
+	return results, nil
+}
+
+// TimeoutMiddleware bounds the execution of the rest of the chain to d, the way
+// router.WithHandlerTimeout bounds a route handler. Non-positive durations disable it.
+func TimeoutMiddleware(d time.Duration) Middleware {
+	return func(next MessageHandlerFunc) MessageHandlerFunc {
+		if d <= 0 {
+			return next
+		}
+
+		return func(ctx context.Context, msg Message) (any, error) {
+			return runWithTimeout(ctx, d, func(ctx context.Context) (any, error) {
+				return next(ctx, msg)
+			})
+		}
+	}
+}
+
+func runWithTimeout(
+	ctx context.Context,
+	timeout time.Duration,
+	fn func(ctx context.Context) (any, error),
+) (any, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	hctx, cancel := context.WithTimeoutCause(ctx, timeout, loafernatsx.ErrHandlerTimeout)
+	defer cancel()
+
+	done := make(chan handlerResult, 1)
+
+	wg, _ := ctx.Value(handlersKey{}).(*sync.WaitGroup)
+	if wg != nil {
+		wg.Add(1)
+	}
+
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				done <- handlerResult{panicVal: r, panicked: true}
+			}
+		}()
+
+		res, err := fn(hctx)
+		done <- handlerResult{result: res, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.unwrap()
+
+	case <-hctx.Done():
+		if !IsHandlerTimeout(hctx) {
+			// The parent context was canceled: let the handler finish as it would without a timeout.
+			return (<-done).unwrap()
+		}
+
+		return nil, loafernatsx.ErrHandlerTimeout
+	}
+}
+
+// IsHandlerTimeout reports whether ctx was canceled because the route handler timeout elapsed.
+func IsHandlerTimeout(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), loafernatsx.ErrHandlerTimeout)
+}
+
+// unwrap re-raises a handler panic on the calling goroutine so it is
+// recovered by the same code path as handlers running without a timeout.
+func (r handlerResult) unwrap() (any, error) {
+	if r.panicked {
+		panic(r.panicVal)
+	}
+
+	return r.result, r.err
+}
@@ -0,0 +1,14 @@
+package dlq
+
+import (
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/producer"
+)
+
+const defaultFetchBatch = 256
+
+type config struct {
+	log       logger.Logger
+	publisher producer.Publisher
+	subject   string
+}
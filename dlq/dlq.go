@@ -0,0 +1,176 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/producer"
+)
+
+// Queue gives access to the messages of a DLQ stream, to inspect failures and
+// replay selected messages to their original subject.
+type Queue struct {
+	js        jetstream.JetStream
+	log       logger.Logger
+	publisher producer.Publisher
+	stream    string
+	subject   string
+}
+
+// New creates a Queue reading the given DLQ stream.
+func New(js jetstream.JetStream, stream string, opts ...Option) (*Queue, error) {
+	if stream == "" {
+		return nil, loafernatsx.ErrMissingDLQStream
+	}
+
+	cfg := config{
+		log: logger.NopLogger{},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.publisher == nil {
+		cfg.publisher = producer.NewJetStreamStrategy(js, cfg.log)
+	}
+
+	return &Queue{
+		js:        js,
+		log:       cfg.log,
+		publisher: cfg.publisher,
+		stream:    stream,
+		subject:   cfg.subject,
+	}, nil
+}
+
+// List returns the DLQ messages currently stored in the stream, oldest first,
+// keeping only those accepted by every filter. Listing does not consume or
+// acknowledge anything, so it can be repeated safely.
+func (q *Queue) List(ctx context.Context, filters ...Filter) ([]*Message, error) {
+	stream, err := q.js.Stream(ctx, q.stream)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.State.Msgs == 0 {
+		return nil, nil
+	}
+
+	cfg := jetstream.OrderedConsumerConfig{}
+	if q.subject != "" {
+		cfg.FilterSubjects = []string{q.subject}
+	}
+
+	cons, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages stored after the listing started are left for the next call.
+	last := info.State.LastSeq
+
+	var out []*Message
+
+	for {
+		batch, fErr := cons.FetchNoWait(defaultFetchBatch)
+		if fErr != nil {
+			return nil, fErr
+		}
+
+		received := 0
+		done := false
+
+		for msg := range batch.Messages() {
+			received++
+
+			meta, mErr := msg.Metadata()
+			if mErr != nil {
+				return nil, mErr
+			}
+
+			m := newMessage(msg, meta)
+			if matches(m, filters) {
+				out = append(out, m)
+			}
+
+			done = meta.Sequence.Stream >= last
+		}
+
+		if bErr := batch.Error(); bErr != nil {
+			return nil, bErr
+		}
+
+		if received == 0 || done {
+			return out, nil
+		}
+	}
+}
+
+// Replay republishes msgs to their original subject. Every message carries a
+// deduplication ID derived from its original stream position, so a message that
+// is replayed twice within the duplicate window of the target stream is only
+// processed once. The failure headers added by the DLQ are removed. Replay
+// continues after a failure and returns the errors of all failed messages.
+func (q *Queue) Replay(ctx context.Context, msgs ...*Message) error {
+	var errs []error
+
+	for _, m := range msgs {
+		if err := q.replay(ctx, m); err != nil {
+			errs = append(errs, fmt.Errorf("replay dlq message %d: %w", m.Sequence, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (q *Queue) replay(ctx context.Context, m *Message) error {
+	if m.OriginalSubject == "" {
+		return loafernatsx.ErrMissingOriginalSubject
+	}
+
+	opts := producer.PublishOptions{}
+	producer.PublishWithMsgID(m.replayID(q.stream))(&opts)
+
+	res, err := q.publisher.Publish(ctx, m.replayMsg(), opts)
+	if err != nil {
+		return err
+	}
+
+	q.log.Info(
+		"dlq message replayed",
+		"dlq_sequence", m.Sequence,
+		"subject", m.OriginalSubject,
+		"duplicate", res.Duplicate,
+	)
+
+	return nil
+}
+
+// Delete removes msgs from the DLQ stream, typically after a successful replay.
+func (q *Queue) Delete(ctx context.Context, msgs ...*Message) error {
+	stream, err := q.js.Stream(ctx, q.stream)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, m := range msgs {
+		if dErr := stream.DeleteMsg(ctx, m.Sequence); dErr != nil {
+			errs = append(errs, fmt.Errorf("delete dlq message %d: %w", m.Sequence, dErr))
+		}
+	}
+
+	return errors.Join(errs...)
+}
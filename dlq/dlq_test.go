@@ -0,0 +1,255 @@
+package dlq_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/dlq"
+	"github.com/silviolleite/loafer-natsx/producer"
+)
+
+func runServer() (*server.Server, string) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	s := natstest.RunServer(&opts)
+	return s, s.ClientURL()
+}
+
+func setup(t *testing.T) jetstream.JetStream {
+	t.Helper()
+
+	s, url := runServer()
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	for name, subject := range map[string]string{"ORDERS": "orders.>", "ORDERS_DLQ": "dlq.orders.>"} {
+		_ = js.DeleteStream(context.Background(), name)
+		_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+			Name:       name,
+			Subjects:   []string{subject},
+			Duplicates: time.Minute,
+		})
+		require.NoError(t, err)
+	}
+
+	return js
+}
+
+func publishFailure(t *testing.T, js jetstream.JetStream, subject, errMsg string, seq uint64, failedAt time.Time) {
+	t.Helper()
+
+	h := nats.Header{}
+	h.Set("X-Tenant", "acme")
+	h.Set(consumer.HeaderErrorKey, errMsg)
+	h.Set(consumer.HeaderRetryCountKey, "3")
+	h.Set(consumer.HeaderOriginalSubjectKey, subject)
+	h.Set(consumer.HeaderOriginalStreamKey, "ORDERS")
+	h.Set(consumer.HeaderOriginalSequenceKey, strconv.FormatUint(seq, 10))
+	h.Set(consumer.HeaderOriginalConsumerKey, "orders-worker")
+	h.Set(consumer.HeaderFirstFailureKey, failedAt.Add(-time.Minute).Format(time.RFC3339Nano))
+	h.Set(consumer.HeaderLastFailureKey, failedAt.Format(time.RFC3339Nano))
+
+	_, err := js.PublishMsg(context.Background(), &nats.Msg{
+		Subject: "dlq." + subject,
+		Header:  h,
+		Data:    []byte("payload-" + strconv.FormatUint(seq, 10)),
+	})
+	require.NoError(t, err)
+}
+
+func TestNew_MissingStream(t *testing.T) {
+	q, err := dlq.New(nil, "")
+	assert.Nil(t, q)
+	assert.ErrorIs(t, err, loafernatsx.ErrMissingDLQStream)
+}
+
+func TestList_Empty(t *testing.T) {
+	js := setup(t)
+
+	q, err := dlq.New(js, "ORDERS_DLQ")
+	require.NoError(t, err)
+
+	msgs, err := q.List(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, msgs)
+}
+
+func TestList_DecodesHeaders(t *testing.T) {
+	js := setup(t)
+
+	failedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	publishFailure(t, js, "orders.created", "db unavailable", 7, failedAt)
+
+	q, err := dlq.New(js, "ORDERS_DLQ")
+	require.NoError(t, err)
+
+	msgs, err := q.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	m := msgs[0]
+	assert.Equal(t, "dlq.orders.created", m.Subject)
+	assert.Equal(t, "db unavailable", m.Error)
+	assert.Equal(t, 3, m.RetryCount)
+	assert.Equal(t, "orders.created", m.OriginalSubject)
+	assert.Equal(t, "ORDERS", m.OriginalStream)
+	assert.Equal(t, uint64(7), m.OriginalSequence)
+	assert.Equal(t, "orders-worker", m.OriginalConsumer)
+	assert.Equal(t, failedAt, m.LastFailure)
+	assert.Equal(t, failedAt.Add(-time.Minute), m.FirstFailure)
+	assert.Equal(t, failedAt, m.FailedAt())
+	assert.Equal(t, "payload-7", string(m.Data))
+	assert.Equal(t, uint64(1), m.Sequence)
+	assert.False(t, m.StoredAt.IsZero())
+}
+
+func TestList_Filters(t *testing.T) {
+	js := setup(t)
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	publishFailure(t, js, "orders.created", "db unavailable", 1, base)
+	publishFailure(t, js, "orders.created", "invalid payload", 2, base.Add(time.Hour))
+	publishFailure(t, js, "orders.cancelled", "db unavailable", 3, base.Add(2*time.Hour))
+
+	q, err := dlq.New(js, "ORDERS_DLQ")
+	require.NoError(t, err)
+
+	msgs, err := q.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, msgs, 3)
+
+	msgs, err = q.List(context.Background(), dlq.ErrorContains("db"))
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 3}, originalSequences(msgs))
+
+	msgs, err = q.List(context.Background(), dlq.FailedSince(base.Add(time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 3}, originalSequences(msgs))
+
+	msgs, err = q.List(context.Background(), dlq.FailedBefore(base.Add(time.Hour)), dlq.OriginalSubject("orders.created"))
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1}, originalSequences(msgs))
+
+	q, err = dlq.New(js, "ORDERS_DLQ", dlq.WithSubject("dlq.orders.cancelled"))
+	require.NoError(t, err)
+
+	msgs, err = q.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3}, originalSequences(msgs))
+}
+
+func TestReplay_PublishesOnceToOriginalSubject(t *testing.T) {
+	js := setup(t)
+
+	publishFailure(t, js, "orders.created", "db unavailable", 42, time.Now())
+
+	q, err := dlq.New(js, "ORDERS_DLQ")
+	require.NoError(t, err)
+
+	msgs, err := q.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	require.NoError(t, q.Replay(context.Background(), msgs...))
+	require.NoError(t, q.Replay(context.Background(), msgs...))
+
+	orders, err := js.Stream(context.Background(), "ORDERS")
+	require.NoError(t, err)
+
+	info, err := orders.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs, "a second replay must be deduplicated")
+
+	raw, err := orders.GetMsg(context.Background(), info.State.LastSeq)
+	require.NoError(t, err)
+	assert.Equal(t, "orders.created", raw.Subject)
+	assert.Equal(t, "payload-42", string(raw.Data))
+	assert.Equal(t, "acme", raw.Header.Get("X-Tenant"))
+	assert.Empty(t, raw.Header.Get(consumer.HeaderErrorKey))
+	assert.Empty(t, raw.Header.Get(consumer.HeaderOriginalSubjectKey))
+	assert.Equal(t, "replay:ORDERS:42", raw.Header.Get(jetstream.MsgIDHeader))
+}
+
+func TestReplay_MissingOriginalSubject(t *testing.T) {
+	js := setup(t)
+
+	q, err := dlq.New(js, "ORDERS_DLQ")
+	require.NoError(t, err)
+
+	err = q.Replay(context.Background(), &dlq.Message{Sequence: 1})
+	assert.ErrorIs(t, err, loafernatsx.ErrMissingOriginalSubject)
+}
+
+type recordingPublisher struct {
+	subjects []string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, msg *nats.Msg, _ producer.PublishOptions) (*producer.PublishResult, error) {
+	if msg.Subject == "orders.broken" {
+		return nil, errors.New("publish failed")
+	}
+	p.subjects = append(p.subjects, msg.Subject)
+	return &producer.PublishResult{}, nil
+}
+
+func TestReplay_CustomPublisherContinuesAfterFailure(t *testing.T) {
+	pub := &recordingPublisher{}
+
+	q, err := dlq.New(nil, "ORDERS_DLQ", dlq.WithPublisher(pub))
+	require.NoError(t, err)
+
+	err = q.Replay(
+		context.Background(),
+		&dlq.Message{Sequence: 1, OriginalSubject: "orders.broken"},
+		&dlq.Message{Sequence: 2, OriginalSubject: "orders.created"},
+	)
+	assert.ErrorContains(t, err, "replay dlq message 1: publish failed")
+	assert.Equal(t, []string{"orders.created"}, pub.subjects)
+}
+
+func TestDelete(t *testing.T) {
+	js := setup(t)
+
+	publishFailure(t, js, "orders.created", "db unavailable", 1, time.Now())
+	publishFailure(t, js, "orders.created", "db unavailable", 2, time.Now())
+
+	q, err := dlq.New(js, "ORDERS_DLQ")
+	require.NoError(t, err)
+
+	msgs, err := q.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	require.NoError(t, q.Delete(context.Background(), msgs[0]))
+
+	msgs, err = q.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2}, originalSequences(msgs))
+}
+
+func originalSequences(msgs []*dlq.Message) []uint64 {
+	out := make([]uint64, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, m.OriginalSequence)
+	}
+	return out
+}
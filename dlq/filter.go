@@ -0,0 +1,46 @@
+package dlq
+
+import (
+	"strings"
+	"time"
+)
+
+// Filter selects DLQ messages. A message is listed only when every filter returns true.
+type Filter func(*Message) bool
+
+// ErrorContains selects messages whose error contains substr.
+func ErrorContains(substr string) Filter {
+	return func(m *Message) bool {
+		return strings.Contains(m.Error, substr)
+	}
+}
+
+// FailedSince selects messages that failed at or after t.
+func FailedSince(t time.Time) Filter {
+	return func(m *Message) bool {
+		return !m.FailedAt().Before(t)
+	}
+}
+
+// FailedBefore selects messages that failed before t.
+func FailedBefore(t time.Time) Filter {
+	return func(m *Message) bool {
+		return m.FailedAt().Before(t)
+	}
+}
+
+// OriginalSubject selects messages first published to subject.
+func OriginalSubject(subject string) Filter {
+	return func(m *Message) bool {
+		return m.OriginalSubject == subject
+	}
+}
+
+func matches(m *Message, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(m) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package dlq
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+)
+
+// Message is a failed message stored in a DLQ stream, decoded from the headers
+// written by the consumer when it gave up on the original message.
+type Message struct {
+	// StoredAt is the time the message was written to the DLQ stream.
+	StoredAt time.Time
+
+	// FirstFailure is the time of the first failed attempt, when known.
+	FirstFailure time.Time
+
+	// LastFailure is the time of the last failed attempt, when known.
+	LastFailure time.Time
+
+	// Headers holds all the headers of the DLQ message.
+	Headers nats.Header
+
+	// Subject is the DLQ subject the message was published to.
+	Subject string
+
+	// Error is the handler error reported in the X-Error header.
+	Error string
+
+	// OriginalSubject is the subject the message was first published to.
+	OriginalSubject string
+
+	// OriginalStream is the stream the message was first stored in.
+	OriginalStream string
+
+	// OriginalConsumer is the JetStream consumer that failed the message.
+	OriginalConsumer string
+
+	// Data is the original message payload.
+	Data []byte
+
+	// Sequence is the sequence of the message in the DLQ stream.
+	Sequence uint64
+
+	// OriginalSequence is the sequence of the message in the original stream.
+	OriginalSequence uint64
+
+	// RetryCount is the number of delivery attempts reported in the X-Retry-Count header.
+	RetryCount int
+}
+
+// failureHeaders are written by the consumer when publishing to the DLQ and are
+// removed when a message is replayed.
+var failureHeaders = []string{
+	consumer.HeaderErrorKey,
+	consumer.HeaderRetryCountKey,
+	consumer.HeaderOriginalSubjectKey,
+	consumer.HeaderOriginalStreamKey,
+	consumer.HeaderOriginalSequenceKey,
+	consumer.HeaderOriginalConsumerKey,
+	consumer.HeaderFirstFailureKey,
+	consumer.HeaderLastFailureKey,
+}
+
+func newMessage(msg jetstream.Msg, meta *jetstream.MsgMetadata) *Message {
+	h := msg.Headers()
+
+	m := &Message{
+		StoredAt:         meta.Timestamp,
+		Headers:          h,
+		Subject:          msg.Subject(),
+		Error:            h.Get(consumer.HeaderErrorKey),
+		OriginalSubject:  h.Get(consumer.HeaderOriginalSubjectKey),
+		OriginalStream:   h.Get(consumer.HeaderOriginalStreamKey),
+		OriginalConsumer: h.Get(consumer.HeaderOriginalConsumerKey),
+		Data:             msg.Data(),
+		Sequence:         meta.Sequence.Stream,
+	}
+
+	m.RetryCount, _ = strconv.Atoi(h.Get(consumer.HeaderRetryCountKey))
+	m.OriginalSequence, _ = strconv.ParseUint(h.Get(consumer.HeaderOriginalSequenceKey), 10, 64)
+	m.FirstFailure, _ = time.Parse(time.RFC3339Nano, h.Get(consumer.HeaderFirstFailureKey))
+	m.LastFailure, _ = time.Parse(time.RFC3339Nano, h.Get(consumer.HeaderLastFailureKey))
+
+	return m
+}
+
+// FailedAt returns the time of the last failure, falling back to the time the
+// message was stored in the DLQ when the failure headers are missing.
+func (m *Message) FailedAt() time.Time {
+	if m.LastFailure.IsZero() {
+		return m.StoredAt
+	}
+	return m.LastFailure
+}
+
+// replayMsg builds the message republished to the original subject. It keeps the
+// application headers and drops the failure context and JetStream headers.
+func (m *Message) replayMsg() *nats.Msg {
+	headers := nats.Header{}
+
+	for k, values := range m.Headers {
+		if strings.HasPrefix(k, "Nats-") {
+			continue
+		}
+		headers[k] = append([]string(nil), values...)
+	}
+
+	for _, k := range failureHeaders {
+		headers.Del(k)
+	}
+
+	return &nats.Msg{
+		Subject: m.OriginalSubject,
+		Header:  headers,
+		Data:    m.Data,
+	}
+}
+
+// replayID is the deduplication ID used when the message is replayed. It is derived
+// from the original stream position, so replaying the same failure twice within the
+// stream duplicate window is discarded by the server.
+func (m *Message) replayID(dlqStream string) string {
+	if m.OriginalStream != "" && m.OriginalSequence > 0 {
+		return "replay:" + m.OriginalStream + ":" + strconv.FormatUint(m.OriginalSequence, 10)
+	}
+
+	return "replay:" + dlqStream + ":" + strconv.FormatUint(m.Sequence, 10)
+}
@@ -0,0 +1,35 @@
+package dlq
+
+import (
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/producer"
+)
+
+// Option configures a Queue during creation.
+type Option func(*config)
+
+// WithSubject restricts the Queue to DLQ messages published to subject, which may
+// contain wildcards (e.g. "dlq.orders.>"). By default every message of the stream is read.
+func WithSubject(subject string) Option {
+	return func(c *config) {
+		c.subject = subject
+	}
+}
+
+// WithPublisher sets the Publisher used to replay messages. It defaults to a
+// JetStream publisher on the same connection, so replays are confirmed by the
+// original stream and deduplicated by message ID.
+func WithPublisher(p producer.Publisher) Option {
+	return func(c *config) {
+		c.publisher = p
+	}
+}
+
+// WithLogger sets the logger used by the Queue.
+func WithLogger(l logger.Logger) Option {
+	return func(c *config) {
+		if l != nil {
+			c.log = l
+		}
+	}
+}
@@ -36,6 +36,99 @@ const (
 
 	// ErrRequestTimeout indicates that a request-reply operation exceeded its deadline.
 	ErrRequestTimeout = Err("request timeout: consumer did not reply in time")
+
+	// ErrInvalidBackoff indicates that a backoff schedule contains a zero or negative delay.
+	ErrInvalidBackoff = Err("backoff delays must be positive")
+
+	// ErrBackoffExceedsMaxDeliver indicates that a backoff schedule has more delays than the max delivery attempts.
+	ErrBackoffExceedsMaxDeliver = Err("backoff cannot have more delays than max deliver attempts")
+
+	// ErrHandlerTimeout indicates that a handler did not complete within the route handler timeout.
+	ErrHandlerTimeout = Err("handler timeout: handler did not complete in time")
+
+	// ErrNotJetStreamMessage indicates that JetStream metadata was requested for a message received through Core NATS.
+	ErrNotJetStreamMessage = Err("message was not delivered by jetstream")
+
+	// ErrInvalidBatchSize indicates that a batch size is zero or negative.
+	ErrInvalidBatchSize = Err("batch size must be positive")
+
+	// ErrBatchRequiresJetStream indicates that batch consumption was requested for a non-jetstream router.
+	ErrBatchRequiresJetStream = Err("batch consumption requires a jetstream router")
+
+	// ErrBatchResultMismatch indicates that a batch handler returned a different number of results than messages.
+	ErrBatchResultMismatch = Err("batch handler must return one result per message")
+
+	// ErrMissingDLQStream indicates that a DLQ stream name is required but was not provided.
+	ErrMissingDLQStream = Err("dlq stream is required")
+
+	// ErrMissingOriginalSubject indicates that a DLQ message cannot be replayed because its original subject is unknown.
+	ErrMissingOriginalSubject = Err("dlq message has no original subject")
+
+	// ErrMissingStreamName indicates that a stream definition was created without a name.
+	ErrMissingStreamName = Err("stream name is required")
+
+	// ErrMissingStreamSubjects indicates that a stream definition was created without subjects.
+	ErrMissingStreamSubjects = Err("stream subjects are required")
+
+	// ErrHandlerPanic indicates that a handler panicked and the panic was recovered by a middleware.
+	ErrHandlerPanic = Err("handler panic")
+
+	// ErrShutdownTimeout indicates that routes still had messages in flight when the shutdown deadline passed.
+	ErrShutdownTimeout = Err("shutdown timeout: routes still had messages in flight")
+
+	// ErrBrokerNotRunning indicates that a route operation was requested while the broker is not running.
+	ErrBrokerNotRunning = Err("broker is not running")
+
+	// ErrDuplicateRoute indicates that a route label is already used by another route of the broker.
+	ErrDuplicateRoute = Err("route is already registered")
+
+	// ErrRouteNotFound indicates that no route of the broker has the requested label.
+	ErrRouteNotFound = Err("route not found")
+
+	// ErrIncompleteClientCert indicates that only one of the client certificate and key files was provided.
+	ErrIncompleteClientCert = Err("client certificate and key files are both required")
+
+	// ErrInvalidTLS indicates that the TLS certificate authority, certificate or key could not be loaded.
+	ErrInvalidTLS = Err("invalid tls configuration")
+
+	// ErrMissingUser indicates that user and password authentication was configured without a user.
+	ErrMissingUser = Err("user is required")
+
+	// ErrMissingToken indicates that token authentication was configured with an empty token.
+	ErrMissingToken = Err("token is required")
+
+	// ErrInvalidCredentials indicates that an NKey seed or credentials file could not be loaded.
+	ErrInvalidCredentials = Err("invalid credentials")
+
+	// ErrConflictingAuth indicates that more than one authentication method was configured for a connection.
+	ErrConflictingAuth = Err("only one authentication method can be configured")
+
+	// ErrDrainTimeout indicates that a connection was closed before draining completed.
+	ErrDrainTimeout = Err("drain timeout: connection closed before draining completed")
+
+	// ErrInvalidConfig indicates that a configuration file or environment variable could not be read or parsed.
+	ErrInvalidConfig = Err("invalid configuration")
+
+	// ErrInvalidMaxAckPending indicates that a max ack pending lower than -1 was configured.
+	ErrInvalidMaxAckPending = Err("max ack pending must be -1 (unlimited) or greater")
+
+	// ErrInvalidInactiveThreshold indicates that a negative consumer inactive threshold was configured.
+	ErrInvalidInactiveThreshold = Err("inactive threshold cannot be negative")
+
+	// ErrInvalidFilterSubject indicates that an additional filter subject is empty or repeats the route subject.
+	ErrInvalidFilterSubject = Err("filter subjects must be non-empty and differ from the route subject")
+
+	// ErrInvalidSampleFrequency indicates that an ack sample frequency outside 0 to 100 percent was configured.
+	ErrInvalidSampleFrequency = Err("sample frequency must be between 0 and 100")
+
+	// ErrInvalidMaxRequestBatch indicates that a max request batch is negative or lower than the batch size.
+	ErrInvalidMaxRequestBatch = Err("max request batch cannot be negative or lower than the batch size")
+
+	// ErrInvalidReplicas indicates that a consumer replica count outside 0 to 5 was configured.
+	ErrInvalidReplicas = Err("consumer replicas must be between 0 and 5")
+
+	// ErrOrderedWithOrderingKey indicates that a route was configured with both WithOrdered and WithOrderingKey.
+	ErrOrderedWithOrderingKey = Err("ordered routes cannot use an ordering key")
 )
 
 // Err represents an error as a string type and implements the error interface.
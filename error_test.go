@@ -31,6 +31,37 @@ func TestSentinelErrors_ErrorStrings(t *testing.T) {
 		{loafernatsx.ErrNilRouteRegistration, "route registration cannot be nil"},
 		{loafernatsx.ErrRequestNotSupported, "request-reply routes are not supported for JetStream producers"},
 		{loafernatsx.ErrRequestTimeout, "request timeout: consumer did not reply in time"},
+		{loafernatsx.ErrInvalidBackoff, "backoff delays must be positive"},
+		{loafernatsx.ErrBackoffExceedsMaxDeliver, "backoff cannot have more delays than max deliver attempts"},
+		{loafernatsx.ErrHandlerTimeout, "handler timeout: handler did not complete in time"},
+		{loafernatsx.ErrNotJetStreamMessage, "message was not delivered by jetstream"},
+		{loafernatsx.ErrInvalidBatchSize, "batch size must be positive"},
+		{loafernatsx.ErrBatchRequiresJetStream, "batch consumption requires a jetstream router"},
+		{loafernatsx.ErrBatchResultMismatch, "batch handler must return one result per message"},
+		{loafernatsx.ErrMissingDLQStream, "dlq stream is required"},
+		{loafernatsx.ErrMissingOriginalSubject, "dlq message has no original subject"},
+		{loafernatsx.ErrMissingStreamName, "stream name is required"},
+		{loafernatsx.ErrMissingStreamSubjects, "stream subjects are required"},
+		{loafernatsx.ErrHandlerPanic, "handler panic"},
+		{loafernatsx.ErrShutdownTimeout, "shutdown timeout: routes still had messages in flight"},
+		{loafernatsx.ErrBrokerNotRunning, "broker is not running"},
+		{loafernatsx.ErrDuplicateRoute, "route is already registered"},
+		{loafernatsx.ErrRouteNotFound, "route not found"},
+		{loafernatsx.ErrIncompleteClientCert, "client certificate and key files are both required"},
+		{loafernatsx.ErrInvalidTLS, "invalid tls configuration"},
+		{loafernatsx.ErrMissingUser, "user is required"},
+		{loafernatsx.ErrMissingToken, "token is required"},
+		{loafernatsx.ErrInvalidCredentials, "invalid credentials"},
+		{loafernatsx.ErrConflictingAuth, "only one authentication method can be configured"},
+		{loafernatsx.ErrDrainTimeout, "drain timeout: connection closed before draining completed"},
+		{loafernatsx.ErrInvalidConfig, "invalid configuration"},
+		{loafernatsx.ErrInvalidMaxAckPending, "max ack pending must be -1 (unlimited) or greater"},
+		{loafernatsx.ErrInvalidInactiveThreshold, "inactive threshold cannot be negative"},
+		{loafernatsx.ErrInvalidFilterSubject, "filter subjects must be non-empty and differ from the route subject"},
+		{loafernatsx.ErrInvalidSampleFrequency, "sample frequency must be between 0 and 100"},
+		{loafernatsx.ErrInvalidMaxRequestBatch, "max request batch cannot be negative or lower than the batch size"},
+		{loafernatsx.ErrInvalidReplicas, "consumer replicas must be between 0 and 5"},
+		{loafernatsx.ErrOrderedWithOrderingKey, "ordered routes cannot use an ordering key"},
 	}
 
 	for _, tt := range tests {
@@ -43,16 +43,16 @@ func main() {
 	}()
 
 	// Connect to NATS
-	nc, err := conn.Connect(
+	c, err := conn.Open(
 		nats.DefaultURL,
 		conn.WithName("orders-broker"),
 		conn.WithMaxReconnects(-1),
+		conn.WithLogger(log),
 	)
 	if err != nil {
 		slog.Error("failed to connect", "error", err)
 		return
 	}
-	defer nc.Drain()
 
 	// Create routes
 	createdRoute, err := router.New(
@@ -81,8 +81,9 @@ func main() {
 
 	// Create broker with metrics
 	br := broker.New(
-		nc,
+		nil,
 		log,
+		broker.WithConnection(c),
 		broker.WithWorkers(2),
 		broker.WithMetrics(prometheus.DefaultRegisterer),
 	)
@@ -124,5 +125,10 @@ func main() {
 		slog.Error("metrics server shutdown error", "error", err)
 	}
 
+	// Drain the connection once the broker stopped
+	if err := c.Close(shutdownCtx); err != nil {
+		slog.Error("connection close error", "error", err)
+	}
+
 	slog.Info("broker shutdown complete")
 }
@@ -38,6 +38,16 @@ func main() {
 		return
 	}
 
+	// The DLQ is published through JetStream, so a stream must capture the DLQ subject.
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     "ORDERS_DLQ",
+		Subjects: []string{"dlq.orders.>"},
+	})
+	if err != nil {
+		slog.Error("failed to create dlq stream", "error", err)
+		return
+	}
+
 	// Consumer engine
 	cons, _ := consumer.New(nc, logger)
 
@@ -91,6 +101,6 @@ func main() {
 	// processing message: {"order_id":"999"}
 	// 2026/02/14 10:17:23 ERROR handler error subject=orders.failed error="simulated processing failure"
 	// DLQ received: {"order_id":"999"}
-	// DLQ headers: map[X-Error:[simulated processing failure] X-Retry-Count:[3]]
+	// DLQ headers: map[Nats-Msg-Id:[ORDERS:orders-dlq-durable:1] X-Error:[simulated processing failure] X-First-Failure:[2026-02-14T13:17:23.101Z] X-Last-Failure:[2026-02-14T13:17:23.105Z] X-Original-Consumer:[orders-dlq-durable] X-Original-Sequence:[1] X-Original-Stream:[ORDERS] X-Original-Subject:[orders.failed] X-Retry-Count:[3]]
 	// DLQ flow completed
 }
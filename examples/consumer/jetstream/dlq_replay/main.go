@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/silviolleite/loafer-natsx/conn"
+	"github.com/silviolleite/loafer-natsx/dlq"
+)
+
+func main() {
+	ctx := context.Background()
+
+	logger := slog.Default()
+
+	nc, err := conn.Connect(
+		nats.DefaultURL,
+		conn.WithName("jetstream-dlq-replay-example"),
+	)
+	if err != nil {
+		slog.Error("failed to connect", "error", err)
+		return
+	}
+	defer nc.Drain()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		slog.Error("failed to create jetstream", "error", err)
+		return
+	}
+
+	queue, err := dlq.New(js, "ORDERS_DLQ", dlq.WithLogger(logger))
+	if err != nil {
+		slog.Error("failed to open dlq", "error", err)
+		return
+	}
+
+	// List the failures of the last day caused by a simulated processing failure.
+	msgs, err := queue.List(
+		ctx,
+		dlq.ErrorContains("simulated"),
+		dlq.FailedSince(time.Now().Add(-24*time.Hour)),
+	)
+	if err != nil {
+		slog.Error("failed to list dlq", "error", err)
+		return
+	}
+
+	for _, m := range msgs {
+		fmt.Printf("dlq seq=%d subject=%s retries=%d error=%q\n", m.Sequence, m.OriginalSubject, m.RetryCount, m.Error)
+	}
+
+	// Replay them to their original subject and drop them from the DLQ.
+	if err = queue.Replay(ctx, msgs...); err != nil {
+		slog.Error("failed to replay", "error", err)
+		return
+	}
+
+	if err = queue.Delete(ctx, msgs...); err != nil {
+		slog.Error("failed to delete replayed messages", "error", err)
+		return
+	}
+
+	// output:
+	// dlq seq=1 subject=orders.failed retries=3 error="simulated processing failure"
+	// 2026/02/14 10:20:05 INFO dlq message replayed dlq_sequence=1 subject=orders.failed duplicate=false
+}
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
-	"github.com/nats-io/nats.go/jetstream"
 
 	"github.com/silviolleite/loafer-natsx/conn"
 	"github.com/silviolleite/loafer-natsx/consumer"
@@ -21,8 +21,8 @@ func main() {
 	logger := slog.Default()
 	slog.SetLogLoggerLevel(slog.LevelDebug)
 
-	// Connect to NATS
-	nc, err := conn.Connect(
+	// Connect to NATS; the connection owns the JetStream context shared below
+	c, err := conn.Open(
 		nats.DefaultURL,
 		conn.WithName("jetstream-durable-example"),
 	)
@@ -30,17 +30,16 @@ func main() {
 		slog.Error("failed to connect", "error", err)
 		return
 	}
-	defer nc.Drain()
-
-	// Create JetStream context
-	js, err := jetstream.New(nc)
-	if err != nil {
-		slog.Error("failed to create jetstream", "error", err)
-		return
-	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if cErr := c.Close(closeCtx); cErr != nil {
+			slog.Error("failed to close connection", "error", cErr)
+		}
+	}()
 
 	// Create consumer engine
-	cons, err := consumer.New(nc, logger)
+	cons, err := consumer.New(nil, logger, consumer.WithConnection(c))
 	if err != nil {
 		slog.Error("failed to create consumer", "error", err)
 		return
@@ -74,7 +73,7 @@ func main() {
 	}
 
 	// Create JetStream producer
-	strategy := jsprod.NewJetStreamStrategy(js, logger)
+	strategy := jsprod.NewJetStreamStrategy(c.JetStream(), logger)
 	prod, err := jsprod.New(strategy, "orders.created")
 	if err != nil {
 		slog.Error("failed to create producer", "error", err)
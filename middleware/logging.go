@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+)
+
+// Logging logs every handler execution at debug level with its route label, the
+// concrete message subject, duration and error, if any.
+func Logging(log logger.Logger) consumer.Middleware {
+	if log == nil {
+		log = logger.NopLogger{}
+	}
+
+	return func(next consumer.MessageHandlerFunc) consumer.MessageHandlerFunc {
+		return func(ctx context.Context, msg consumer.Message) (any, error) {
+			start := time.Now()
+
+			res, err := next(ctx, msg)
+
+			args := []any{
+				"route", labelOf(ctx, msg),
+				"message_subject", msg.Subject(),
+				"duration", time.Since(start),
+			}
+
+			if err != nil {
+				log.Debug("message handled with error", append(args, "error", err)...)
+				return res, err
+			}
+
+			log.Debug("message handled", args...)
+			return res, nil
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+)
+
+// Recorder receives the measurements of handler executions.
+type Recorder interface {
+	// HandlerStarted is called before the handler runs.
+	HandlerStarted(route string)
+
+	// HandlerFinished is called after the handler returns with its duration and error.
+	HandlerFinished(route string, duration time.Duration, err error)
+}
+
+// Metrics reports every handler execution to rec, labeled with the route label: its
+// name set with router.WithName, or its subject.
+func Metrics(rec Recorder) consumer.Middleware {
+	return func(next consumer.MessageHandlerFunc) consumer.MessageHandlerFunc {
+		if rec == nil {
+			return next
+		}
+
+		return func(ctx context.Context, msg consumer.Message) (any, error) {
+			route := labelOf(ctx, msg)
+			start := time.Now()
+
+			rec.HandlerStarted(route)
+
+			res, err := next(ctx, msg)
+
+			rec.HandlerFinished(route, time.Since(start), err)
+
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+)
+
+// labelOf returns the label of a handler execution: the route label when available,
+// that is its name or its subject, which keeps wildcard routes to a single label, or
+// the concrete message subject otherwise.
+func labelOf(ctx context.Context, msg consumer.Message) string {
+	if r, ok := consumer.RouteFromContext(ctx); ok {
+		return r.Label()
+	}
+	return msg.Subject()
+}
@@ -0,0 +1,225 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/middleware"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+type logEntry struct {
+	level string
+	msg   string
+	args  []any
+}
+
+type recordingLogger struct {
+	entries []logEntry
+	mu      sync.Mutex
+}
+
+func (l *recordingLogger) add(level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, args: args})
+}
+
+func (l *recordingLogger) Info(msg string, args ...any)  { l.add("info", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.add("error", msg, args) }
+func (l *recordingLogger) Debug(msg string, args ...any) { l.add("debug", msg, args) }
+
+func newMessage(subject string) consumer.Message {
+	return consumer.NewMessage(&nats.Msg{Subject: subject, Data: []byte("data")})
+}
+
+func ok(context.Context, consumer.Message) (any, error) {
+	return "ok", nil
+}
+
+func TestLogging(t *testing.T) {
+	log := &recordingLogger{}
+	boom := errors.New("boom")
+
+	h := middleware.Logging(log)(ok)
+	res, err := h(context.Background(), newMessage("orders.created"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	h = middleware.Logging(log)(func(context.Context, consumer.Message) (any, error) {
+		return nil, boom
+	})
+	_, err = h(context.Background(), newMessage("orders.created"))
+	assert.ErrorIs(t, err, boom)
+
+	assert.Len(t, log.entries, 2)
+	assert.Equal(t, "message handled", log.entries[0].msg)
+	assert.Equal(t, "message handled with error", log.entries[1].msg)
+	assert.Contains(t, log.entries[1].args, boom)
+
+	assert.NotPanics(t, func() {
+		_, _ = middleware.Logging(nil)(ok)(context.Background(), newMessage("orders.created"))
+	})
+}
+
+func TestRecover(t *testing.T) {
+	log := &recordingLogger{}
+
+	h := middleware.Recover(log)(func(context.Context, consumer.Message) (any, error) {
+		panic("boom")
+	})
+
+	res, err := h(context.Background(), newMessage("orders.created"))
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, loafernatsx.ErrHandlerPanic)
+	assert.EqualError(t, err, "handler panic: boom")
+	assert.Len(t, log.entries, 1)
+
+	res, err = middleware.Recover(nil)(ok)(context.Background(), newMessage("orders.created"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+}
+
+func TestTimeout(t *testing.T) {
+	h := middleware.Timeout(50 * time.Millisecond)(func(ctx context.Context, _ consumer.Message) (any, error) {
+		<-ctx.Done()
+		assert.True(t, consumer.IsHandlerTimeout(ctx))
+		return nil, ctx.Err()
+	})
+
+	_, err := h(context.Background(), newMessage("orders.created"))
+	assert.ErrorIs(t, err, loafernatsx.ErrHandlerTimeout)
+
+	res, err := middleware.Timeout(time.Second)(ok)(context.Background(), newMessage("orders.created"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	res, err = middleware.Timeout(0)(ok)(context.Background(), newMessage("orders.created"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+}
+
+func TestTimeout_PropagatesPanic(t *testing.T) {
+	h := middleware.Recover(nil)(middleware.Timeout(time.Second)(func(context.Context, consumer.Message) (any, error) {
+		panic("boom")
+	}))
+
+	_, err := h(context.Background(), newMessage("orders.created"))
+	assert.ErrorIs(t, err, loafernatsx.ErrHandlerPanic)
+}
+
+type recorder struct {
+	started  []string
+	finished []string
+	errs     []error
+}
+
+func (r *recorder) HandlerStarted(subject string) {
+	r.started = append(r.started, subject)
+}
+
+func (r *recorder) HandlerFinished(subject string, d time.Duration, err error) {
+	r.finished = append(r.finished, fmt.Sprintf("%s:%t", subject, d >= 0))
+	r.errs = append(r.errs, err)
+}
+
+func TestMetrics(t *testing.T) {
+	rec := &recorder{}
+	boom := errors.New("boom")
+
+	_, _ = middleware.Metrics(rec)(ok)(context.Background(), newMessage("orders.created"))
+	_, _ = middleware.Metrics(rec)(func(context.Context, consumer.Message) (any, error) {
+		return nil, boom
+	})(context.Background(), newMessage("orders.cancelled"))
+
+	assert.Equal(t, []string{"orders.created", "orders.cancelled"}, rec.started)
+	assert.Equal(t, []string{"orders.created:true", "orders.cancelled:true"}, rec.finished)
+	assert.Equal(t, []error{nil, boom}, rec.errs)
+
+	res, err := middleware.Metrics(nil)(ok)(context.Background(), newMessage("orders.created"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+}
+
+func TestMetrics_RouteLabel(t *testing.T) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	s := natstest.RunServer(&opts)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+
+	rec := &recorder{}
+
+	c, err := consumer.New(nc, logger.NopLogger{}, consumer.WithMiddleware(middleware.Metrics(rec)))
+	require.NoError(t, err)
+
+	r, err := router.New(router.TypePubSub, "orders.*", router.WithName("orders"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handled := make(chan struct{})
+
+	require.NoError(t, c.StartMessage(ctx, r, func(context.Context, consumer.Message) (any, error) {
+		close(handled)
+		return nil, nil
+	}))
+	require.NoError(t, nc.Publish("orders.created", []byte("data")))
+
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not handled")
+	}
+
+	cancel()
+	require.NoError(t, c.Wait(context.Background()))
+
+	assert.Equal(t, []string{"orders"}, rec.started)
+}
+
+type spanKey struct{}
+
+type tracer struct {
+	ended []error
+}
+
+func (tr *tracer) Start(ctx context.Context, _ consumer.Message) (context.Context, func(error)) {
+	return context.WithValue(ctx, spanKey{}, "span"), func(err error) {
+		tr.ended = append(tr.ended, err)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tr := &tracer{}
+	boom := errors.New("boom")
+
+	h := middleware.Tracing(tr)(func(ctx context.Context, _ consumer.Message) (any, error) {
+		assert.Equal(t, "span", ctx.Value(spanKey{}))
+		return nil, boom
+	})
+
+	_, err := h(context.Background(), newMessage("orders.created"))
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, []error{boom}, tr.ended)
+
+	res, err := middleware.Tracing(nil)(ok)(context.Background(), newMessage("orders.created"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+}
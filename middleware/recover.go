@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+)
+
+// Recover turns a handler panic into an error wrapping loafernatsx.ErrHandlerPanic,
+// so the message follows the regular failure path (e.g. a JetStream Nak) instead of
+// waiting for the ack deadline.
+func Recover(log logger.Logger) consumer.Middleware {
+	if log == nil {
+		log = logger.NopLogger{}
+	}
+
+	return func(next consumer.MessageHandlerFunc) consumer.MessageHandlerFunc {
+		return func(ctx context.Context, msg consumer.Message) (res any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("handler panic recovered", "subject", msg.Subject(), "panic", r)
+					res, err = nil, fmt.Errorf("%w: %v", loafernatsx.ErrHandlerPanic, r)
+				}
+			}()
+
+			return next(ctx, msg)
+		}
+	}
+}
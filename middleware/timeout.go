@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+)
+
+// Timeout bounds the execution of the rest of the chain to d. The handler receives
+// a context canceled with cause loafernatsx.ErrHandlerTimeout, and once d elapses
+// the middleware returns ErrHandlerTimeout without waiting for the handler.
+// Prefer router.WithHandlerTimeout for a per-route limit; Timeout is useful to apply
+// one limit to several routes. Non-positive durations disable the middleware.
+// It is consumer.TimeoutMiddleware.
+func Timeout(d time.Duration) consumer.Middleware {
+	return consumer.TimeoutMiddleware(d)
+}
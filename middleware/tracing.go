@@ -0,0 +1,10 @@
+package middleware
+
+import "github.com/silviolleite/loafer-natsx/consumer"
+
+// Tracing wraps every handler execution in a span started by tracer. The handler
+// receives the context returned by the tracer, so spans it creates become children
+// of the consumer span. It is consumer.TracingMiddleware.
+func Tracing(tracer consumer.Tracer) consumer.Middleware {
+	return consumer.TracingMiddleware(tracer)
+}
@@ -0,0 +1,85 @@
+package natsotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+var _ consumer.Tracer = (*ConsumerTracer)(nil)
+
+// ConsumerTracer implements consumer.Tracer. It extracts the trace context from the
+// message headers and starts a consumer span, child of the producer span, around
+// the handler.
+type ConsumerTracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewConsumerTracer creates a ConsumerTracer. Register it with consumer.WithTracer,
+// broker.WithTracer or middleware.Tracing.
+func NewConsumerTracer(opts ...Option) *ConsumerTracer {
+	cfg := newConfig(opts)
+
+	return &ConsumerTracer{
+		tracer:     cfg.provider.Tracer(instrumentationName),
+		propagator: cfg.propagator,
+	}
+}
+
+// Start starts a "process <subject>" span for msg. When the message is consumed by a
+// route, the span is named after the route subject so wildcard routes produce a
+// single span name.
+func (t *ConsumerTracer) Start(ctx context.Context, msg consumer.Message) (spanCtx context.Context, end func(err error)) {
+	ctx = t.propagator.Extract(ctx, headerCarrier(msg.Headers()))
+
+	destination := msg.Subject()
+	attrs := []attribute.KeyValue{
+		systemNATS,
+		semconv.MessagingOperationTypeProcess,
+		semconv.MessagingOperationName("process"),
+		semconv.MessagingDestinationName(msg.Subject()),
+		semconv.MessagingMessageBodySize(len(msg.Data())),
+	}
+
+	if route, ok := consumer.RouteFromContext(ctx); ok {
+		destination = route.Subject()
+		attrs = append(attrs, routeAttributes(route)...)
+	}
+
+	if meta, err := msg.Metadata(); err == nil {
+		attrs = append(attrs,
+			StreamKey.String(meta.Stream),
+			StreamSequenceKey.Int64(int64(meta.Sequence.Stream)),
+			DeliveryCountKey.Int64(int64(meta.NumDelivered)),
+		)
+	}
+
+	ctx, span := t.tracer.Start(
+		ctx,
+		"process "+destination,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx, endSpan(span)
+}
+
+func routeAttributes(route *router.Route) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.MessagingDestinationTemplate(route.Subject())}
+
+	switch {
+	case route.Durable() != "":
+		attrs = append(attrs, semconv.MessagingConsumerGroupName(route.Durable()))
+	case route.QueueGroup() != "":
+		attrs = append(attrs, semconv.MessagingConsumerGroupName(route.QueueGroup()))
+	}
+
+	return attrs
+}
@@ -0,0 +1,261 @@
+package natsotel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/silviolleite/loafer-natsx/consumer"
+	"github.com/silviolleite/loafer-natsx/logger"
+	"github.com/silviolleite/loafer-natsx/natsotel"
+	"github.com/silviolleite/loafer-natsx/producer"
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+func runServer() (*server.Server, string) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	s := natstest.RunServer(&opts)
+	return s, s.ClientURL()
+}
+
+func setupTracing() (*tracetest.SpanRecorder, []natsotel.Option) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	return rec, []natsotel.Option{
+		natsotel.WithTracerProvider(tp),
+		natsotel.WithPropagator(propagation.TraceContext{}),
+	}
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	out := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		out[kv.Key] = kv.Value
+	}
+	return out
+}
+
+func TestTracing_PublishToConsumer(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	rec, opts := setupTracing()
+
+	cons, err := consumer.New(nc, logger.NopLogger{}, consumer.WithTracer(natsotel.NewConsumerTracer(opts...)))
+	require.NoError(t, err)
+
+	route, err := router.New(router.TypePubSub, "orders.*")
+	require.NoError(t, err)
+
+	handled := make(chan trace.SpanContext, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = cons.StartMessage(ctx, route, func(ctx context.Context, msg consumer.Message) (any, error) {
+		handled <- trace.SpanContextFromContext(ctx)
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+
+	p, err := producer.New(
+		producer.NewCoreStrategy(nc),
+		"orders.created",
+		producer.WithTracer(natsotel.NewProducerTracer(opts...)),
+	)
+	require.NoError(t, err)
+
+	_, err = p.Publish(context.Background(), []byte("payload"))
+	require.NoError(t, err)
+
+	var handlerSpan trace.SpanContext
+	select {
+	case handlerSpan = <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not handled")
+	}
+
+	require.Eventually(t, func() bool { return len(rec.Ended()) == 2 }, 2*time.Second, 10*time.Millisecond)
+
+	spans := rec.Ended()
+	send, process := spans[0], spans[1]
+	if send.SpanKind() != trace.SpanKindProducer {
+		send, process = process, send
+	}
+
+	assert.Equal(t, "send orders.created", send.Name())
+	assert.Equal(t, trace.SpanKindProducer, send.SpanKind())
+	assert.Equal(t, "nats", attributes(send)["messaging.system"].AsString())
+	assert.Equal(t, "orders.created", attributes(send)["messaging.destination.name"].AsString())
+	assert.Equal(t, int64(len("payload")), attributes(send)["messaging.message.body.size"].AsInt64())
+
+	assert.Equal(t, "process orders.*", process.Name())
+	assert.Equal(t, trace.SpanKindConsumer, process.SpanKind())
+	assert.Equal(t, send.SpanContext().SpanID(), process.Parent().SpanID())
+	assert.Equal(t, send.SpanContext().TraceID(), process.SpanContext().TraceID())
+	assert.Equal(t, process.SpanContext().SpanID(), handlerSpan.SpanID())
+	assert.Equal(t, "orders.created", attributes(process)["messaging.destination.name"].AsString())
+	assert.Equal(t, "orders.*", attributes(process)["messaging.destination.template"].AsString())
+	assert.Equal(t, "process", attributes(process)["messaging.operation.type"].AsString())
+}
+
+func TestProducerTracer_Request(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	rec, opts := setupTracing()
+
+	sub, err := nc.Subscribe("orders.get", func(msg *nats.Msg) {
+		_ = msg.Respond([]byte(msg.Header.Get(consumer.HeaderTraceParentKey)))
+	})
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+
+	p, err := producer.New(
+		producer.NewCoreStrategy(nc),
+		"orders.get",
+		producer.WithTracer(natsotel.NewProducerTracer(opts...)),
+	)
+	require.NoError(t, err)
+
+	resp, err := p.Request(context.Background(), []byte("id"))
+	require.NoError(t, err)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+
+	sc := spans[0].SpanContext()
+	assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", string(resp.Data))
+}
+
+func TestProducerTracer_DefaultPropagatorUsesW3CHeaders(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	headers := make(chan nats.Header, 1)
+
+	sub, err := nc.Subscribe("orders.created", func(msg *nats.Msg) {
+		headers <- msg.Header
+	})
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+
+	tp := sdktrace.NewTracerProvider()
+
+	p, err := producer.New(
+		producer.NewCoreStrategy(nc),
+		"orders.created",
+		producer.WithTracer(natsotel.NewProducerTracer(natsotel.WithTracerProvider(tp))),
+	)
+	require.NoError(t, err)
+
+	member, err := baggage.NewMember("tenant", "acme")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	_, err = p.Publish(baggage.ContextWithBaggage(context.Background(), bag), []byte("payload"))
+	require.NoError(t, err)
+
+	select {
+	case h := <-headers:
+		assert.NotEmpty(t, h.Get(consumer.HeaderTraceParentKey))
+		assert.Equal(t, "tenant=acme", h.Get(consumer.HeaderBaggageKey))
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestProducerTracer_KeepsCallerHeaders(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	_, opts := setupTracing()
+
+	p, err := producer.New(
+		producer.NewCoreStrategy(nc),
+		"orders.created",
+		producer.WithTracer(natsotel.NewProducerTracer(opts...)),
+	)
+	require.NoError(t, err)
+
+	headers := nats.Header{"X-Tenant": []string{"acme"}}
+
+	for range 2 {
+		_, err = p.Publish(context.Background(), []byte("payload"), producer.PublishWithHeaders(headers))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, nats.Header{"X-Tenant": []string{"acme"}}, headers)
+}
+
+type jetStreamMessage struct {
+	consumer.Message
+	meta *jetstream.MsgMetadata
+}
+
+func (m jetStreamMessage) Metadata() (*jetstream.MsgMetadata, error) {
+	return m.meta, nil
+}
+
+func TestConsumerTracer_JetStreamAttributesAndError(t *testing.T) {
+	rec, opts := setupTracing()
+
+	msg := jetStreamMessage{
+		Message: consumer.NewMessage(&nats.Msg{Subject: "orders.created", Data: []byte("{}")}),
+		meta: &jetstream.MsgMetadata{
+			Sequence:     jetstream.SequencePair{Stream: 42, Consumer: 7},
+			NumDelivered: 3,
+			Stream:       "ORDERS",
+		},
+	}
+
+	_, end := natsotel.NewConsumerTracer(opts...).Start(context.Background(), msg)
+	end(errors.New("boom"))
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+
+	attrs := attributes(spans[0])
+	assert.Equal(t, "process orders.created", spans[0].Name())
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Equal(t, "ORDERS", attrs[natsotel.StreamKey].AsString())
+	assert.Equal(t, int64(42), attrs[natsotel.StreamSequenceKey].AsInt64())
+	assert.Equal(t, int64(3), attrs[natsotel.DeliveryCountKey].AsInt64())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+}
@@ -0,0 +1,31 @@
+package natsotel
+
+import (
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures a tracer during creation.
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider used to create spans. It defaults to
+// the global provider returned by otel.GetTracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		if tp != nil {
+			c.provider = tp
+		}
+	}
+}
+
+// WithPropagator sets the propagator used to inject and extract the trace context
+// from message headers. It defaults to the W3C trace context and baggage propagators,
+// which use the traceparent, tracestate and baggage headers; pass
+// otel.GetTextMapPropagator() to follow the global propagator instead.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		if p != nil {
+			c.propagator = p
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package natsotel
+
+import (
+	"context"
+	"slices"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/silviolleite/loafer-natsx/producer"
+)
+
+var _ producer.Tracer = (*ProducerTracer)(nil)
+
+// ProducerTracer implements producer.Tracer. It starts a producer span for every
+// published message and injects its context into the message headers.
+type ProducerTracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewProducerTracer creates a ProducerTracer. Register it with producer.WithTracer.
+func NewProducerTracer(opts ...Option) *ProducerTracer {
+	cfg := newConfig(opts)
+
+	return &ProducerTracer{
+		tracer:     cfg.provider.Tracer(instrumentationName),
+		propagator: cfg.propagator,
+	}
+}
+
+// Start starts a "send <subject>" span and injects the trace context into msg. The
+// headers are copied first, as they may belong to the caller, for instance when set
+// with producer.PublishWithHeaders.
+func (t *ProducerTracer) Start(ctx context.Context, msg *nats.Msg) (spanCtx context.Context, end func(err error)) {
+	ctx, span := t.tracer.Start(
+		ctx,
+		"send "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			systemNATS,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingOperationName("send"),
+			semconv.MessagingDestinationName(msg.Subject),
+			semconv.MessagingMessageBodySize(len(msg.Data)),
+		),
+	)
+
+	header := make(nats.Header, len(msg.Header))
+	for k, v := range msg.Header {
+		header[k] = slices.Clone(v)
+	}
+	msg.Header = header
+
+	t.propagator.Inject(ctx, headerCarrier(msg.Header))
+
+	return ctx, endSpan(span)
+}
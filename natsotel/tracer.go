@@ -0,0 +1,50 @@
+package natsotel
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attributes recorded for JetStream messages, which have no standard semantic convention.
+const (
+	// StreamKey is the attribute key of the JetStream stream that stored the message.
+	StreamKey = attribute.Key("messaging.nats.stream")
+
+	// StreamSequenceKey is the attribute key of the message sequence in its stream.
+	StreamSequenceKey = attribute.Key("messaging.nats.stream.sequence")
+
+	// DeliveryCountKey is the attribute key of the number of times the message was delivered.
+	DeliveryCountKey = attribute.Key("messaging.nats.message.delivery_count")
+)
+
+var systemNATS = semconv.MessagingSystemKey.String("nats")
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// endSpan returns a function that records err on span, if any, and ends it.
+func endSpan(span trace.Span) func(err error) {
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.SetAttributes(semconv.ErrorType(err))
+		}
+
+		span.End()
+	}
+}
@@ -10,6 +10,8 @@ const defaultRequestTimeout = 10 * time.Second
 
 type config struct {
 	log            logger.Logger
+	tracer         Tracer
+	metrics        Metrics
 	subject        string
 	requestTimeout time.Duration
 }
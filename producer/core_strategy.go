@@ -40,3 +40,16 @@ func (c *coreStrategy) Request(
 	}
 	return &Response{Data: msg.Data, Header: msg.Header}, nil
 }
+
+// RequestMsg sends msg as a request and waits for a response using Core NATS,
+// keeping the headers of msg.
+func (c *coreStrategy) RequestMsg(
+	ctx context.Context,
+	msg *nats.Msg,
+) (*Response, error) {
+	resp, err := c.nc.RequestMsgWithContext(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Data: resp.Data, Header: resp.Header}, nil
+}
@@ -15,6 +15,22 @@ type Publisher interface {
 	Publish(ctx context.Context, msg *nats.Msg, opts PublishOptions) (*PublishResult, error)
 }
 
+// MessageRequester is implemented by Requesters able to send a request carrying headers.
+// Producer uses it when a Tracer injected trace context into the request message.
+type MessageRequester interface {
+
+	// RequestMsg sends msg as a request, waits for a response and returns a *Response
+	// containing the reply data and headers, or an error.
+	RequestMsg(ctx context.Context, msg *nats.Msg) (*Response, error)
+}
+
+// Tracer starts a span for an outgoing message. Start may add headers to msg to
+// propagate the trace context and returns a function that ends the span with the
+// publish error.
+type Tracer interface {
+	Start(ctx context.Context, msg *nats.Msg) (context.Context, func(err error))
+}
+
 // Requester defines an interface for sending requests with a subject and data and receiving a response.
 type Requester interface {
 
@@ -95,6 +95,9 @@ func TestJetStreamStrategy_Publish_Duplicate(t *testing.T) {
 	opts := natstest.DefaultTestOptions
 	opts.Port = -1
 	opts.JetStream = true
+	// A store of its own: with the shared default store, the stream and the message ID
+	// of a previous run are still there, so the first publish is already a duplicate.
+	opts.StoreDir = t.TempDir()
 
 	s := natstest.RunServer(&opts)
 	defer s.Shutdown()
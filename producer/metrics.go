@@ -0,0 +1,188 @@
+package producer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+)
+
+// Payload size buckets, in bytes: 64 B up to 1 MiB.
+const (
+	payloadBucketStart  = 64
+	payloadBucketFactor = 4
+	payloadBucketCount  = 8
+)
+
+// Strategy labels reported to Metrics.
+const (
+	StrategyCore      = "core"
+	StrategyJetStream = "jetstream"
+	StrategyCustom    = "custom"
+)
+
+// Metrics records the publish and request operations of producers. Every method
+// receives the producer subject and strategy (StrategyCore, StrategyJetStream or
+// StrategyCustom). Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObservePublish records a publish of size payload bytes that took d and failed with err, if not nil.
+	ObservePublish(subject, strategy string, size int, d time.Duration, err error)
+
+	// IncDuplicate counts a JetStream publish acknowledged as a duplicate.
+	IncDuplicate(subject, strategy string)
+
+	// ObserveRequest records a request that took d and failed with err, if not nil.
+	ObserveRequest(subject, strategy string, d time.Duration, err error)
+}
+
+// PrometheusMetrics implements Metrics with Prometheus collectors labeled by subject and strategy.
+type PrometheusMetrics struct {
+	publishedTotal  *prometheus.CounterVec
+	errorsTotal     *prometheus.CounterVec
+	duplicatesTotal *prometheus.CounterVec
+	publishDuration *prometheus.HistogramVec
+	payloadBytes    *prometheus.HistogramVec
+	requestsTotal   *prometheus.CounterVec
+	requestErrors   *prometheus.CounterVec
+	requestTimeouts *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+// NewPrometheusMetrics creates the producer Prometheus collectors and registers them with reg.
+// It panics if the collectors are already registered.
+func NewPrometheusMetrics(reg prometheus.Registerer) *PrometheusMetrics {
+	labels := []string{"subject", "strategy"}
+
+	m := &PrometheusMetrics{
+		publishedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "loafer_producer_published_total",
+				Help: "Total published messages",
+			},
+			labels,
+		),
+		errorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "loafer_producer_publish_errors_total",
+				Help: "Total failed publishes",
+			},
+			labels,
+		),
+		duplicatesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "loafer_producer_duplicates_total",
+				Help: "Total JetStream publishes acknowledged as duplicates",
+			},
+			labels,
+		),
+		publishDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "loafer_producer_publish_duration_seconds",
+				Help:    "Publish duration",
+				Buckets: prometheus.DefBuckets,
+			},
+			labels,
+		),
+		payloadBytes: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "loafer_producer_payload_bytes",
+				Help:    "Published payload size",
+				Buckets: prometheus.ExponentialBuckets(payloadBucketStart, payloadBucketFactor, payloadBucketCount),
+			},
+			labels,
+		),
+		requestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "loafer_producer_requests_total",
+				Help: "Total requests that received a reply",
+			},
+			labels,
+		),
+		requestErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "loafer_producer_request_errors_total",
+				Help: "Total failed requests, including timeouts",
+			},
+			labels,
+		),
+		requestTimeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "loafer_producer_request_timeouts_total",
+				Help: "Total requests that did not receive a reply in time",
+			},
+			labels,
+		),
+		requestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "loafer_producer_request_duration_seconds",
+				Help:    "Request-reply duration",
+				Buckets: prometheus.DefBuckets,
+			},
+			labels,
+		),
+	}
+
+	reg.MustRegister(
+		m.publishedTotal,
+		m.errorsTotal,
+		m.duplicatesTotal,
+		m.publishDuration,
+		m.payloadBytes,
+		m.requestsTotal,
+		m.requestErrors,
+		m.requestTimeouts,
+		m.requestDuration,
+	)
+
+	return m
+}
+
+// ObservePublish records the publish duration and payload size, and increments
+// loafer_producer_published_total or loafer_producer_publish_errors_total.
+func (m *PrometheusMetrics) ObservePublish(subject, strategy string, size int, d time.Duration, err error) {
+	m.publishDuration.WithLabelValues(subject, strategy).Observe(d.Seconds())
+	m.payloadBytes.WithLabelValues(subject, strategy).Observe(float64(size))
+
+	if err != nil {
+		m.errorsTotal.WithLabelValues(subject, strategy).Inc()
+		return
+	}
+
+	m.publishedTotal.WithLabelValues(subject, strategy).Inc()
+}
+
+// IncDuplicate increments the loafer_producer_duplicates_total counter.
+func (m *PrometheusMetrics) IncDuplicate(subject, strategy string) {
+	m.duplicatesTotal.WithLabelValues(subject, strategy).Inc()
+}
+
+// ObserveRequest records the request duration and increments loafer_producer_requests_total
+// or loafer_producer_request_errors_total, along with loafer_producer_request_timeouts_total
+// when err is loafernatsx.ErrRequestTimeout.
+func (m *PrometheusMetrics) ObserveRequest(subject, strategy string, d time.Duration, err error) {
+	m.requestDuration.WithLabelValues(subject, strategy).Observe(d.Seconds())
+
+	if err == nil {
+		m.requestsTotal.WithLabelValues(subject, strategy).Inc()
+		return
+	}
+
+	m.requestErrors.WithLabelValues(subject, strategy).Inc()
+
+	if errors.Is(err, loafernatsx.ErrRequestTimeout) {
+		m.requestTimeouts.WithLabelValues(subject, strategy).Inc()
+	}
+}
+
+func strategyOf(p Publisher) string {
+	switch p.(type) {
+	case *coreStrategy:
+		return StrategyCore
+	case *jetStreamStrategy:
+		return StrategyJetStream
+	default:
+		return StrategyCustom
+	}
+}
@@ -0,0 +1,117 @@
+package producer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/producer"
+)
+
+type duplicatePublisher struct{}
+
+func (duplicatePublisher) Publish(context.Context, *nats.Msg, producer.PublishOptions) (*producer.PublishResult, error) {
+	return &producer.PublishResult{Stream: "ORDERS", Sequence: 1, Duplicate: true}, nil
+}
+
+func TestWithMetrics_Publish(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := producer.NewPrometheusMetrics(reg)
+
+	ok, _ := producer.New(&mockPublisher{}, "orders.created", producer.WithMetricsBackend(m))
+	failing, _ := producer.New(&mockPublisher{err: errors.New("fail")}, "orders.created", producer.WithMetricsBackend(m))
+	dup, _ := producer.New(duplicatePublisher{}, "orders.created", producer.WithMetricsBackend(m))
+
+	_, err := ok.Publish(context.Background(), []byte("data"))
+	require.NoError(t, err)
+	_, err = failing.Publish(context.Background(), []byte("data"))
+	require.Error(t, err)
+	_, err = dup.Publish(context.Background(), []byte("data"))
+	require.NoError(t, err)
+
+	labels := []string{"orders.created", producer.StrategyCustom}
+
+	assert.InDelta(t, 2, metricValue(t, reg, "loafer_producer_published_total", labels), 0)
+	assert.InDelta(t, 1, metricValue(t, reg, "loafer_producer_publish_errors_total", labels), 0)
+	assert.InDelta(t, 1, metricValue(t, reg, "loafer_producer_duplicates_total", labels), 0)
+	assert.InDelta(t, 3, metricValue(t, reg, "loafer_producer_publish_duration_seconds", labels), 0)
+	assert.InDelta(t, 3, metricValue(t, reg, "loafer_producer_payload_bytes", labels), 0)
+}
+
+func TestWithMetrics_RequestTimeout(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	p, _ := producer.New(
+		&mockRequester{blockUntilCtxDone: true},
+		"orders.get",
+		producer.WithMetrics(reg),
+		producer.WithRequestTimeout(20*time.Millisecond),
+	)
+
+	_, err := p.Request(context.Background(), []byte("id"))
+	require.Error(t, err)
+
+	labels := []string{"orders.get", producer.StrategyCustom}
+
+	assert.InDelta(t, 1, metricValue(t, reg, "loafer_producer_request_errors_total", labels), 0)
+	assert.InDelta(t, 1, metricValue(t, reg, "loafer_producer_request_timeouts_total", labels), 0)
+	assert.InDelta(t, 0, metricValue(t, reg, "loafer_producer_requests_total", labels), 0)
+}
+
+func TestWithMetrics_CoreStrategyLabel(t *testing.T) {
+	s, url := runServer()
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	reg := prometheus.NewRegistry()
+
+	p, _ := producer.New(producer.NewCoreStrategy(nc), "orders.created", producer.WithMetrics(reg))
+
+	_, err = p.Publish(context.Background(), []byte("data"))
+	require.NoError(t, err)
+
+	labels := []string{"orders.created", producer.StrategyCore}
+	assert.InDelta(t, 1, metricValue(t, reg, "loafer_producer_published_total", labels), 0)
+}
+
+// metricValue returns the value of a counter or the sample count of a histogram.
+func metricValue(t *testing.T, reg *prometheus.Registry, name string, labels []string) float64 {
+	t.Helper()
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			values := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				values[l.GetName()] = l.GetValue()
+			}
+
+			if values["subject"] != labels[0] || values["strategy"] != labels[1] {
+				continue
+			}
+
+			if h := m.GetHistogram(); h != nil {
+				return float64(h.GetSampleCount())
+			}
+
+			return m.GetCounter().GetValue()
+		}
+	}
+
+	return 0
+}
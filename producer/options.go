@@ -3,6 +3,8 @@ package producer
 import (
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/silviolleite/loafer-natsx/logger"
 )
 
@@ -16,6 +18,30 @@ func WithLogger(logger logger.Logger) Option {
 	}
 }
 
+// WithTracer sets a Tracer that starts a span for every published message and
+// request, propagating its context through the message headers.
+func WithTracer(tracer Tracer) Option {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
+
+// WithMetrics sets up Prometheus metrics using the provided Registerer.
+// It is equivalent to WithMetricsBackend(NewPrometheusMetrics(reg)).
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.metrics = NewPrometheusMetrics(reg)
+	}
+}
+
+// WithMetricsBackend sets the Metrics implementation that records publish and
+// request operations. A nil backend disables metrics.
+func WithMetricsBackend(m Metrics) Option {
+	return func(c *config) {
+		c.metrics = m
+	}
+}
+
 // WithRequestTimeout sets a maximum duration for request-reply operations.
 // When set, the Producer wraps the caller's context with this deadline before
 // sending the request. This prevents the producer from waiting indefinitely
@@ -15,7 +15,10 @@ import (
 type Producer struct {
 	log            logger.Logger
 	publisher      Publisher
+	tracer         Tracer
+	metrics        Metrics
 	subject        string
+	strategy       string
 	requestTimeout time.Duration
 }
 
@@ -48,6 +51,9 @@ func New(
 		subject:        cfg.subject,
 		log:            cfg.log,
 		publisher:      publisher,
+		tracer:         cfg.tracer,
+		metrics:        cfg.metrics,
+		strategy:       strategyOf(publisher),
 		requestTimeout: cfg.requestTimeout,
 	}, nil
 }
@@ -81,11 +87,65 @@ func (p *Producer) Publish(
 		"headers_count", len(msg.Header),
 	)
 
-	return p.publisher.Publish(ctx, msg, pubCfg)
+	start := time.Now()
+
+	res, err := p.publish(ctx, msg, pubCfg)
+
+	p.observePublish(len(data), time.Since(start), res, err)
+
+	return res, err
+}
+
+func (p *Producer) publish(
+	ctx context.Context,
+	msg *nats.Msg,
+	pubCfg PublishOptions,
+) (*PublishResult, error) {
+	if p.tracer == nil {
+		return p.publisher.Publish(ctx, msg, pubCfg)
+	}
+
+	ctx, end := p.tracer.Start(ctx, msg)
+	res, err := p.publisher.Publish(ctx, msg, pubCfg)
+	end(err)
+
+	return res, err
+}
+
+func (p *Producer) observePublish(size int, d time.Duration, res *PublishResult, err error) {
+	duplicate := err == nil && res != nil && res.Duplicate
+
+	switch {
+	case err != nil:
+		p.log.Error(
+			"publish failed",
+			"subject", p.subject,
+			"strategy", p.strategy,
+			"error", err,
+		)
+	case duplicate:
+		p.log.Info(
+			"publish acknowledged as duplicate",
+			"subject", p.subject,
+			"stream", res.Stream,
+			"sequence", res.Sequence,
+		)
+	}
+
+	if p.metrics == nil {
+		return
+	}
+
+	p.metrics.ObservePublish(p.subject, p.strategy, size, d, err)
+
+	if duplicate {
+		p.metrics.IncDuplicate(p.subject, p.strategy)
+	}
 }
 
 // Request sends a request to the configured subject with the provided data and waits for a response.
 // Only Core NATS producers support request operations.
+// When a Tracer is configured, the request carries the trace context in its headers.
 // When a request timeout is configured via WithRequestTimeout, the context is
 // wrapped with a deadline so the call does not block indefinitely if the
 // consumer becomes unavailable.
@@ -106,14 +166,56 @@ func (p *Producer) Request(
 		defer cancel()
 	}
 
-	resp, err := r.Request(ctx, p.subject, data)
-	if err != nil {
-		if ctx.Err() != nil {
-			return nil, fmt.Errorf("%w: %w", loafernatsx.ErrRequestTimeout, err)
-		}
+	start := time.Now()
+
+	resp, err := p.request(ctx, r, data)
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("%w: %w", loafernatsx.ErrRequestTimeout, err)
+	}
 
+	p.observeRequest(time.Since(start), err)
+
+	if err != nil {
 		return nil, err
 	}
 
 	return resp, nil
 }
+
+func (p *Producer) request(
+	ctx context.Context,
+	r Requester,
+	data []byte,
+) (*Response, error) {
+	mr, ok := r.(MessageRequester)
+	if p.tracer == nil || !ok {
+		return r.Request(ctx, p.subject, data)
+	}
+
+	msg := &nats.Msg{
+		Subject: p.subject,
+		Data:    data,
+	}
+
+	ctx, end := p.tracer.Start(ctx, msg)
+	resp, err := mr.RequestMsg(ctx, msg)
+	end(err)
+
+	return resp, err
+}
+
+func (p *Producer) observeRequest(d time.Duration, err error) {
+	if err != nil {
+		p.log.Error(
+			"request failed",
+			"subject", p.subject,
+			"strategy", p.strategy,
+			"duration", d,
+			"error", err,
+		)
+	}
+
+	if p.metrics != nil {
+		p.metrics.ObserveRequest(p.subject, p.strategy, d, err)
+	}
+}
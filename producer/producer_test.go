@@ -233,3 +233,50 @@ func TestRequest_WithRequestTimeout(t *testing.T) {
 		assert.False(t, errors.Is(err, loafernatsx.ErrRequestTimeout))
 	})
 }
+
+type mockTracer struct {
+	err     error
+	started bool
+	ended   bool
+}
+
+func (m *mockTracer) Start(ctx context.Context, msg *nats.Msg) (context.Context, func(err error)) {
+	m.started = true
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	msg.Header.Set("traceparent", "trace")
+
+	return ctx, func(err error) {
+		m.ended = true
+		m.err = err
+	}
+}
+
+func TestPublish_WithTracer(t *testing.T) {
+	mp := &mockPublisher{err: errors.New("fail")}
+	tr := &mockTracer{}
+
+	p, _ := producer.New(mp, "test.subject", producer.WithTracer(tr))
+
+	_, err := p.Publish(context.Background(), []byte("data"))
+	assert.Error(t, err)
+	assert.True(t, tr.started)
+	assert.True(t, tr.ended)
+	assert.Equal(t, err, tr.err)
+	assert.Equal(t, "trace", mp.msg.Header.Get("traceparent"))
+}
+
+func TestRequest_WithTracerWithoutMessageRequester(t *testing.T) {
+	mr := &mockRequester{
+		response: &producer.Response{Data: []byte("ok")},
+	}
+	tr := &mockTracer{}
+
+	p, _ := producer.New(mr, "test.subject", producer.WithTracer(tr))
+
+	resp, err := p.Request(context.Background(), []byte("data"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ok"), resp.Data)
+	assert.False(t, tr.started)
+}
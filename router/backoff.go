@@ -0,0 +1,54 @@
+package router
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// backoffFactor is the growth factor between consecutive exponential backoff delays.
+const backoffFactor = 2
+
+// FixedBackoff returns a redelivery schedule made of the given delays.
+// The n-th delay applies after the n-th failed delivery; the last delay is
+// reused for any further attempt.
+func FixedBackoff(delays ...time.Duration) []time.Duration {
+	out := make([]time.Duration, len(delays))
+	copy(out, delays)
+	return out
+}
+
+// ExponentialBackoff returns a redelivery schedule of steps delays that starts at
+// initial and doubles on every step, capped at maxDelay. The schedule is
+// deterministic; use WithBackoffJitter to randomize the delays of every retry.
+// It returns nil when initial or steps are not positive.
+func ExponentialBackoff(initial, maxDelay time.Duration, steps int) []time.Duration {
+	if initial <= 0 || steps <= 0 {
+		return nil
+	}
+
+	if maxDelay < initial {
+		maxDelay = initial
+	}
+
+	out := make([]time.Duration, 0, steps)
+	delay := initial
+
+	for range steps {
+		out = append(out, delay)
+
+		delay = min(delay*backoffFactor, maxDelay)
+	}
+
+	return out
+}
+
+// jitter spreads d uniformly over [d*(1-fraction), d*(1+fraction)], keeping it positive.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+
+	d = time.Duration(float64(d) * (1 + fraction*(2*rand.Float64()-1)))
+
+	return max(d, time.Millisecond)
+}
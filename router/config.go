@@ -11,15 +11,39 @@ import (
 // handlerErr is the error returned by the handler (if any).
 type ReplyFunc func(ctx context.Context, result any, handlerErr error) ([]byte, nats.Header, error)
 
+// DLQSubjectFunc builds the DLQ subject of a failed message from the concrete
+// subject the message was published to.
+type DLQSubjectFunc func(subject string) string
+
 type config struct {
-	reply          ReplyFunc
-	subject        string
-	queueGroup     string
-	stream         string
-	durable        string
-	ackWait        time.Duration
-	maxDeliver     int
-	handlerTimeout time.Duration
-	deliveryPolicy DeliverPolicy
-	enableDLQ      bool
+	reply             ReplyFunc
+	dlqSubjectFunc    DLQSubjectFunc
+	orderingKey       KeyFunc
+	metadata          map[string]string
+	name              string
+	subject           string
+	queueGroup        string
+	stream            string
+	durable           string
+	dlqSubject        string
+	description       string
+	backoff           []time.Duration
+	filterSubjects    []string
+	ackWait           time.Duration
+	backoffJitter     float64
+	batchMaxWait      time.Duration
+	maxDeliver        int
+	batchSize         int
+	concurrency       int
+	handlerTimeout    time.Duration
+	inactiveThreshold time.Duration
+	maxAckPending     int
+	sampleFrequency   int
+	maxRequestBatch   int
+	replicas          int
+	deliveryPolicy    DeliverPolicy
+	enableDLQ         bool
+	ordered           bool
+	headersOnly       bool
+	memoryStorage     bool
 }
@@ -1,6 +1,9 @@
 package router
 
-import "time"
+import (
+	"maps"
+	"time"
+)
 
 // Option configures a Route during creation.
 type Option func(*config)
@@ -40,13 +43,85 @@ func WithMaxDeliver(n int) Option {
 	}
 }
 
-// WithHandlerTimeout sets the handler execution timeout.
+// WithBackoff sets the redelivery schedule for a JetStream router. It defines the
+// delay used when a failed message is negatively acknowledged. JetStream also uses
+// a consumer BackOff in place of AckWait, as the ack deadline of every delivery, so
+// the schedule is only sent to the consumer when its first delay is at least the
+// route AckWait; a shorter first delay leaves the ack deadline to AckWait.
+// Use FixedBackoff or ExponentialBackoff to build the schedule. The number of
+// delays cannot exceed the max delivery attempts.
+func WithBackoff(delays []time.Duration) Option {
+	return func(c *config) {
+		c.backoff = delays
+	}
+}
+
+// WithBackoffJitter randomizes every delay of the WithBackoff schedule by up to
+// ±fraction (between 0 and 1) each time a failed message is negatively acknowledged,
+// so the retries of messages failing together are spread out. The schedule sent to
+// the JetStream consumer is not randomized.
+func WithBackoffJitter(fraction float64) Option {
+	return func(c *config) {
+		c.backoffJitter = min(max(fraction, 0), 1)
+	}
+}
+
+// WithHandlerTimeout sets the handler execution timeout. Once it passes, the handler
+// context is canceled and the message is handled as failed with
+// loafernatsx.ErrHandlerTimeout: requests get an error reply and JetStream messages
+// are Nak'ed. A handler that ignores its context keeps its worker until it returns,
+// so it still counts against the route concurrency and ordering, and shutdown waits for it.
 func WithHandlerTimeout(d time.Duration) Option {
 	return func(c *config) {
 		c.handlerTimeout = d
 	}
 }
 
+// WithConcurrency sets how many messages of this route are processed in parallel.
+// Messages still arrive through a single subscription (or JetStream consumer) and
+// are handed to a bounded pool of n goroutines. A concurrency of 1 processes
+// messages sequentially in delivery order. Values lower than 1 are ignored and the
+// consumer default applies.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithOrdered makes the route process messages strictly sequentially in delivery
+// order, regardless of the configured concurrency. It cannot be combined with
+// WithOrderingKey.
+func WithOrdered() Option {
+	return func(c *config) {
+		c.ordered = true
+	}
+}
+
+// WithOrderingKey enables key-based ordering: messages that share the key returned by
+// fn are processed sequentially in delivery order, while messages with different keys
+// run concurrently up to the route concurrency. Messages without a key share one lane.
+// See KeyFromHeader and KeyFromSubjectToken. It cannot be combined with WithOrdered,
+// which already serializes every message.
+func WithOrderingKey(fn KeyFunc) Option {
+	return func(c *config) {
+		c.orderingKey = fn
+	}
+}
+
+// WithBatch enables pull-based batch consumption for a JetStream router. The consumer
+// fetches up to size messages and hands them to a batch handler together, waiting at
+// most maxWait for the batch to fill; whatever arrived by then is delivered as a
+// smaller batch. A maxWait of zero or less uses the default of one second.
+// Batch routes are consumed with consumer.StartBatch.
+func WithBatch(size int, maxWait time.Duration) Option {
+	return func(c *config) {
+		c.batchSize = size
+		c.batchMaxWait = maxWait
+	}
+}
+
 // WithReply sets the reply function for request-reply consumers.
 func WithReply(r ReplyFunc) Option {
 	return func(c *config) {
@@ -61,9 +136,118 @@ func WithEnableDLQ() Option {
 	}
 }
 
+// WithDLQSubject enables the DLQ and publishes failed messages to subject instead
+// of the default "dlq.<subject>". The subject must be bound to a JetStream stream.
+func WithDLQSubject(subject string) Option {
+	return func(c *config) {
+		c.enableDLQ = true
+		c.dlqSubject = subject
+	}
+}
+
+// WithDLQSubjectFunc enables the DLQ and derives the DLQ subject of every failed
+// message from its concrete subject, which is useful for wildcard routes.
+// It takes precedence over WithDLQSubject.
+func WithDLQSubjectFunc(fn DLQSubjectFunc) Option {
+	return func(c *config) {
+		c.enableDLQ = true
+		c.dlqSubjectFunc = fn
+	}
+}
+
 // WithDeliveryPolicy sets the message delivery policy for a JetStream consumer and returns an Option to apply this change.
 func WithDeliveryPolicy(policy DeliverPolicy) Option {
 	return func(c *config) {
 		c.deliveryPolicy = policy
 	}
 }
+
+// WithName sets a name identifying the route in metrics and logs. Named routes
+// are reported under their name instead of their subject, which keeps wildcard
+// routes and routes sharing a subject apart.
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithMaxAckPending sets how many messages the JetStream consumer delivers without
+// an acknowledgement before pausing delivery. -1 means unlimited; zero keeps the
+// server default.
+func WithMaxAckPending(n int) Option {
+	return func(c *config) {
+		c.maxAckPending = n
+	}
+}
+
+// WithInactiveThreshold makes the server delete the JetStream consumer after it had
+// no subscription for d. Zero keeps durable consumers forever.
+func WithInactiveThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.inactiveThreshold = d
+	}
+}
+
+// WithFilterSubjects filters the JetStream consumer on the given subjects in addition
+// to the route subject, so one route consumes several subjects of its stream.
+func WithFilterSubjects(subjects ...string) Option {
+	return func(c *config) {
+		c.filterSubjects = append(c.filterSubjects, subjects...)
+	}
+}
+
+// WithHeadersOnly makes the JetStream consumer deliver the message headers without
+// the payload; the payload size is set in the Nats-Msg-Size header.
+func WithHeadersOnly() Option {
+	return func(c *config) {
+		c.headersOnly = true
+	}
+}
+
+// WithSampleFrequency sets the percentage, between 0 and 100, of acknowledgements
+// the server samples into consumer ack advisories. Zero disables sampling.
+func WithSampleFrequency(percent int) Option {
+	return func(c *config) {
+		c.sampleFrequency = percent
+	}
+}
+
+// WithMaxRequestBatch sets the largest batch a single pull request can ask for. It
+// cannot be lower than the batch size set with WithBatch. Zero means no limit.
+func WithMaxRequestBatch(n int) Option {
+	return func(c *config) {
+		c.maxRequestBatch = n
+	}
+}
+
+// WithReplicas sets the number of replicas of the JetStream consumer state, at most 5.
+// Zero inherits the replicas of the stream.
+func WithReplicas(n int) Option {
+	return func(c *config) {
+		c.replicas = n
+	}
+}
+
+// WithMemoryStorage keeps the JetStream consumer state in memory instead of on disk.
+func WithMemoryStorage() Option {
+	return func(c *config) {
+		c.memoryStorage = true
+	}
+}
+
+// WithMetadata sets metadata stored with the JetStream consumer. Calls are merged.
+func WithMetadata(metadata map[string]string) Option {
+	return func(c *config) {
+		if c.metadata == nil {
+			c.metadata = make(map[string]string, len(metadata))
+		}
+		maps.Copy(c.metadata, metadata)
+	}
+}
+
+// WithDescription sets the description of the JetStream consumer.
+func WithDescription(description string) Option {
+	return func(c *config) {
+		c.description = description
+	}
+}
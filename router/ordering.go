@@ -0,0 +1,42 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// KeyFunc extracts an ordering key from a message subject and headers.
+// Messages that share a key are processed sequentially in delivery order,
+// while messages with different keys may run concurrently.
+type KeyFunc func(subject string, headers nats.Header) string
+
+// KeyFromHeader returns a KeyFunc that uses the value of the given header as the ordering key.
+func KeyFromHeader(name string) KeyFunc {
+	return func(_ string, headers nats.Header) string {
+		if headers == nil {
+			return ""
+		}
+		return headers.Get(name)
+	}
+}
+
+// KeyFromSubjectToken returns a KeyFunc that uses a token of the concrete message subject
+// as the ordering key. Tokens are zero-indexed; negative indexes count from the end, so
+// -1 selects the last token (e.g. the order ID in "orders.updated.<id>").
+func KeyFromSubjectToken(index int) KeyFunc {
+	return func(subject string, _ nats.Header) string {
+		tokens := strings.Split(subject, ".")
+
+		i := index
+		if i < 0 {
+			i += len(tokens)
+		}
+
+		if i < 0 || i >= len(tokens) {
+			return ""
+		}
+
+		return tokens[i]
+	}
+}
@@ -9,6 +9,14 @@ import (
 const (
 	defaultMaxDeliveries = 10
 	defaultAckWait       = 30 * time.Second
+	defaultBatchMaxWait  = time.Second
+	defaultDLQPrefix     = "dlq."
+
+	// maxSampleFrequency is the highest ack sampling percentage.
+	maxSampleFrequency = 100
+
+	// maxReplicas is the highest number of replicas JetStream supports.
+	maxReplicas = 5
 )
 
 // Route represents a message consumption route definition.
@@ -22,6 +30,20 @@ func (r *Route) Type() Type {
 	return r.routeType
 }
 
+// Name returns the route name, or an empty string when none was set.
+func (r *Route) Name() string {
+	return r.cfg.name
+}
+
+// Label returns the route name when set, or the route subject otherwise.
+// It identifies the route in metrics.
+func (r *Route) Label() string {
+	if r.cfg.name != "" {
+		return r.cfg.name
+	}
+	return r.cfg.subject
+}
+
 // Subject returns the route subject.
 func (r *Route) Subject() string {
 	return r.cfg.subject
@@ -52,11 +74,75 @@ func (r *Route) MaxDeliver() int {
 	return r.cfg.maxDeliver
 }
 
+// Backoff returns the JetStream redelivery schedule, if configured.
+func (r *Route) Backoff() []time.Duration {
+	return r.cfg.backoff
+}
+
+// BackoffJitter returns the fraction by which BackoffDelay randomizes the schedule.
+func (r *Route) BackoffJitter() float64 {
+	return r.cfg.backoffJitter
+}
+
+// BackoffDelay returns the redelivery delay after the given delivery attempt (starting at 1),
+// randomized on every call by the route backoff jitter. Attempts beyond the schedule reuse
+// its last delay. It returns zero when no backoff is configured.
+func (r *Route) BackoffDelay(attempt int) time.Duration {
+	n := len(r.cfg.backoff)
+	if n == 0 {
+		return 0
+	}
+
+	idx := min(max(attempt-1, 0), n-1)
+	return jitter(r.cfg.backoff[idx], r.cfg.backoffJitter)
+}
+
+// Concurrency returns the number of messages processed in parallel, or zero when
+// the route relies on the consumer default.
+func (r *Route) Concurrency() int {
+	return r.cfg.concurrency
+}
+
+// Ordered indicates whether messages are processed strictly sequentially.
+func (r *Route) Ordered() bool {
+	return r.cfg.ordered
+}
+
+// OrderingKey returns the key function used for key-based ordering, if configured.
+func (r *Route) OrderingKey() KeyFunc {
+	return r.cfg.orderingKey
+}
+
+// BatchSize returns the maximum number of messages per batch, or zero when batch
+// consumption is not configured.
+func (r *Route) BatchSize() int {
+	return r.cfg.batchSize
+}
+
+// BatchMaxWait returns how long the consumer waits for a batch to fill.
+func (r *Route) BatchMaxWait() time.Duration {
+	return r.cfg.batchMaxWait
+}
+
 // DLQEnabled indicates whether DLQ is enabled.
 func (r *Route) DLQEnabled() bool {
 	return r.cfg.enableDLQ
 }
 
+// DLQSubject returns the DLQ subject for a message published to subject. Without a
+// custom subject or subject function it defaults to "dlq." followed by subject.
+func (r *Route) DLQSubject(subject string) string {
+	if r.cfg.dlqSubjectFunc != nil {
+		return r.cfg.dlqSubjectFunc(subject)
+	}
+
+	if r.cfg.dlqSubject != "" {
+		return r.cfg.dlqSubject
+	}
+
+	return defaultDLQPrefix + subject
+}
+
 // ReplyFunc returns the reply function if configured.
 func (r *Route) ReplyFunc() ReplyFunc {
 	return r.cfg.reply
@@ -72,6 +158,60 @@ func (r *Route) DeliveryPolicy() DeliverPolicy {
 	return r.cfg.deliveryPolicy
 }
 
+// MaxAckPending returns the maximum number of unacknowledged messages, or zero for the
+// server default.
+func (r *Route) MaxAckPending() int {
+	return r.cfg.maxAckPending
+}
+
+// InactiveThreshold returns how long the JetStream consumer may stay without a
+// subscription before the server deletes it, or zero.
+func (r *Route) InactiveThreshold() time.Duration {
+	return r.cfg.inactiveThreshold
+}
+
+// FilterSubjects returns the subjects the JetStream consumer is filtered on: the route
+// subject followed by the subjects set with WithFilterSubjects.
+func (r *Route) FilterSubjects() []string {
+	return append([]string{r.cfg.subject}, r.cfg.filterSubjects...)
+}
+
+// HeadersOnly indicates whether only the message headers are delivered.
+func (r *Route) HeadersOnly() bool {
+	return r.cfg.headersOnly
+}
+
+// SampleFrequency returns the percentage of sampled acknowledgements.
+func (r *Route) SampleFrequency() int {
+	return r.cfg.sampleFrequency
+}
+
+// MaxRequestBatch returns the largest batch a pull request can ask for, or zero.
+func (r *Route) MaxRequestBatch() int {
+	return r.cfg.maxRequestBatch
+}
+
+// Replicas returns the number of replicas of the JetStream consumer, or zero to
+// inherit them from the stream.
+func (r *Route) Replicas() int {
+	return r.cfg.replicas
+}
+
+// MemoryStorage indicates whether the JetStream consumer state is kept in memory.
+func (r *Route) MemoryStorage() bool {
+	return r.cfg.memoryStorage
+}
+
+// Metadata returns the metadata stored with the JetStream consumer.
+func (r *Route) Metadata() map[string]string {
+	return r.cfg.metadata
+}
+
+// Description returns the description of the JetStream consumer.
+func (r *Route) Description() string {
+	return r.cfg.description
+}
+
 // New creates a validated Route definition applying default values when necessary.
 func New(routeType Type, subject string, opts ...Option) (*Route, error) {
 	cfg := &config{
@@ -86,6 +226,10 @@ func New(routeType Type, subject string, opts ...Option) (*Route, error) {
 		return nil, loafernatsx.ErrMissingSubject
 	}
 
+	if cfg.ordered && cfg.orderingKey != nil {
+		return nil, loafernatsx.ErrOrderedWithOrderingKey
+	}
+
 	switch routeType {
 	case TypeQueue:
 		if cfg.queueGroup == "" {
@@ -93,17 +237,8 @@ func New(routeType Type, subject string, opts ...Option) (*Route, error) {
 		}
 
 	case TypeJetStream:
-		if cfg.stream == "" {
-			return nil, loafernatsx.ErrMissingStream
-		}
-		if cfg.durable == "" {
-			return nil, loafernatsx.ErrMissingDurable
-		}
-		if cfg.maxDeliver == 0 {
-			cfg.maxDeliver = defaultMaxDeliveries
-		}
-		if cfg.ackWait == 0 {
-			cfg.ackWait = defaultAckWait
+		if err := validateJetStream(cfg); err != nil {
+			return nil, err
 		}
 
 	case TypeRequestReply:
@@ -123,3 +258,71 @@ func New(routeType Type, subject string, opts ...Option) (*Route, error) {
 		cfg:       cfg,
 	}, nil
 }
+
+// validateJetStream validates the JetStream specific settings and applies their defaults.
+func validateJetStream(cfg *config) error {
+	if cfg.stream == "" {
+		return loafernatsx.ErrMissingStream
+	}
+	if cfg.durable == "" {
+		return loafernatsx.ErrMissingDurable
+	}
+	if cfg.maxDeliver == 0 {
+		cfg.maxDeliver = defaultMaxDeliveries
+	}
+	if cfg.ackWait == 0 {
+		cfg.ackWait = defaultAckWait
+	}
+	if err := validateBackoff(cfg.backoff, cfg.maxDeliver); err != nil {
+		return err
+	}
+	if cfg.batchSize < 0 {
+		return loafernatsx.ErrInvalidBatchSize
+	}
+	if cfg.batchSize > 0 && cfg.batchMaxWait <= 0 {
+		cfg.batchMaxWait = defaultBatchMaxWait
+	}
+
+	return validateConsumer(cfg)
+}
+
+// validateConsumer validates the JetStream consumer settings.
+func validateConsumer(cfg *config) error {
+	if cfg.maxAckPending < -1 {
+		return loafernatsx.ErrInvalidMaxAckPending
+	}
+	if cfg.inactiveThreshold < 0 {
+		return loafernatsx.ErrInvalidInactiveThreshold
+	}
+	for _, s := range cfg.filterSubjects {
+		if s == "" || s == cfg.subject {
+			return loafernatsx.ErrInvalidFilterSubject
+		}
+	}
+	if cfg.sampleFrequency < 0 || cfg.sampleFrequency > maxSampleFrequency {
+		return loafernatsx.ErrInvalidSampleFrequency
+	}
+	if cfg.maxRequestBatch < 0 || (cfg.maxRequestBatch > 0 && cfg.maxRequestBatch < cfg.batchSize) {
+		return loafernatsx.ErrInvalidMaxRequestBatch
+	}
+	if cfg.replicas < 0 || cfg.replicas > maxReplicas {
+		return loafernatsx.ErrInvalidReplicas
+	}
+
+	return nil
+}
+
+func validateBackoff(backoff []time.Duration, maxDeliver int) error {
+	for _, d := range backoff {
+		if d <= 0 {
+			return loafernatsx.ErrInvalidBackoff
+		}
+	}
+
+	// JetStream accepts at most one delay per delivery attempt; -1 means unlimited deliveries.
+	if maxDeliver != -1 && len(backoff) > maxDeliver {
+		return loafernatsx.ErrBackoffExceedsMaxDeliver
+	}
+
+	return nil
+}
@@ -149,4 +149,354 @@ func TestDLQEnabled(t *testing.T) {
 	)
 	assert.NoError(t, err)
 	assert.True(t, r.DLQEnabled())
+	assert.Equal(t, "dlq.orders.failed", r.DLQSubject("orders.failed"))
+}
+
+func TestWithDLQSubject(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.*",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithDLQSubject("orders-dlq.all"),
+	)
+	assert.NoError(t, err)
+	assert.True(t, r.DLQEnabled())
+	assert.Equal(t, "orders-dlq.all", r.DLQSubject("orders.created"))
+}
+
+func TestWithDLQSubjectFunc(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.*",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithDLQSubject("ignored"),
+		router.WithDLQSubjectFunc(func(subject string) string {
+			return "failed." + subject
+		}),
+	)
+	assert.NoError(t, err)
+	assert.True(t, r.DLQEnabled())
+	assert.Equal(t, "failed.orders.created", r.DLQSubject("orders.created"))
+}
+
+func TestNew_JetStream_Backoff(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithMaxDeliver(4),
+		router.WithBackoff(router.FixedBackoff(time.Second, 5*time.Second, 30*time.Second)),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}, r.Backoff())
+	assert.Equal(t, time.Second, r.BackoffDelay(0))
+	assert.Equal(t, time.Second, r.BackoffDelay(1))
+	assert.Equal(t, 5*time.Second, r.BackoffDelay(2))
+	assert.Equal(t, 30*time.Second, r.BackoffDelay(3))
+	assert.Equal(t, 30*time.Second, r.BackoffDelay(10))
+}
+
+func TestNew_JetStream_NoBackoffDelay(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+	)
+	assert.NoError(t, err)
+	assert.Empty(t, r.Backoff())
+	assert.Zero(t, r.BackoffDelay(1))
+}
+
+func TestNew_JetStream_InvalidBackoff(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithBackoff(router.FixedBackoff(time.Second, 0)),
+	)
+	assert.Nil(t, r)
+	assert.ErrorIs(t, err, loafernastx.ErrInvalidBackoff)
+}
+
+func TestNew_JetStream_BackoffExceedsMaxDeliver(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithMaxDeliver(2),
+		router.WithBackoff(router.FixedBackoff(time.Second, time.Second, time.Second)),
+	)
+	assert.Nil(t, r)
+	assert.ErrorIs(t, err, loafernastx.ErrBackoffExceedsMaxDeliver)
+
+	r, err = router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithMaxDeliver(-1),
+		router.WithBackoff(router.FixedBackoff(time.Second, time.Second, time.Second)),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, r.Backoff(), 3)
+}
+
+func TestFixedBackoff_CopiesDelays(t *testing.T) {
+	delays := []time.Duration{time.Second, 2 * time.Second}
+	b := router.FixedBackoff(delays...)
+	delays[0] = time.Hour
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, b)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := router.ExponentialBackoff(time.Second, 5*time.Second, 5)
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}, b)
+
+	assert.Nil(t, router.ExponentialBackoff(0, time.Second, 3))
+	assert.Nil(t, router.ExponentialBackoff(time.Second, time.Second, 0))
+}
+
+func TestBackoffDelay_Jitter(t *testing.T) {
+	schedule := router.ExponentialBackoff(time.Second, time.Minute, 4)
+
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithBackoff(schedule),
+		router.WithBackoffJitter(0.5),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, schedule, r.Backoff(), "the schedule itself is not randomized")
+	assert.Equal(t, 0.5, r.BackoffJitter())
+
+	seen := make(map[time.Duration]bool)
+	for range 20 {
+		d := r.BackoffDelay(2)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.LessOrEqual(t, d, 3*time.Second)
+		seen[d] = true
+	}
+	assert.Greater(t, len(seen), 1, "every call draws its own jitter")
+}
+
+func TestWithConcurrency(t *testing.T) {
+	r, err := router.New(router.TypePubSub, "orders.created", router.WithConcurrency(4))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, r.Concurrency())
+
+	r, err = router.New(router.TypePubSub, "orders.created", router.WithConcurrency(0))
+	assert.NoError(t, err)
+	assert.Zero(t, r.Concurrency())
+}
+
+func TestWithOrdered(t *testing.T) {
+	r, err := router.New(router.TypePubSub, "orders.created", router.WithOrdered())
+	assert.NoError(t, err)
+	assert.True(t, r.Ordered())
+	assert.Nil(t, r.OrderingKey())
+}
+
+func TestWithOrderingKey(t *testing.T) {
+	r, err := router.New(
+		router.TypePubSub,
+		"orders.*",
+		router.WithOrderingKey(router.KeyFromHeader("X-Order-ID")),
+	)
+	assert.NoError(t, err)
+	assert.False(t, r.Ordered())
+	assert.NotNil(t, r.OrderingKey())
+
+	h := nats.Header{}
+	h.Set("X-Order-ID", "42")
+	assert.Equal(t, "42", r.OrderingKey()("orders.updated", h))
+	assert.Empty(t, r.OrderingKey()("orders.updated", nil))
+}
+
+func TestNew_OrderedWithOrderingKey(t *testing.T) {
+	r, err := router.New(
+		router.TypePubSub,
+		"orders.*",
+		router.WithOrdered(),
+		router.WithOrderingKey(router.KeyFromSubjectToken(-1)),
+	)
+	assert.Nil(t, r)
+	assert.ErrorIs(t, err, loafernastx.ErrOrderedWithOrderingKey)
+}
+
+func TestKeyFromSubjectToken(t *testing.T) {
+	assert.Equal(t, "orders", router.KeyFromSubjectToken(0)("orders.updated.42", nil))
+	assert.Equal(t, "updated", router.KeyFromSubjectToken(1)("orders.updated.42", nil))
+	assert.Equal(t, "42", router.KeyFromSubjectToken(-1)("orders.updated.42", nil))
+	assert.Equal(t, "orders", router.KeyFromSubjectToken(-3)("orders.updated.42", nil))
+	assert.Empty(t, router.KeyFromSubjectToken(3)("orders.updated.42", nil))
+	assert.Empty(t, router.KeyFromSubjectToken(-4)("orders.updated.42", nil))
+}
+
+func TestKeyFromSubjectToken_ReusedAcrossSubjects(t *testing.T) {
+	last := router.KeyFromSubjectToken(-1)
+
+	assert.Equal(t, "42", last("orders.updated.42", nil))
+	assert.Equal(t, "42", last("orders.42", nil))
+	assert.Equal(t, "7", last("orders.updated.items.7", nil))
+	assert.Equal(t, "42", last("orders.updated.42", nil))
+}
+
+func TestNew_JetStream_Batch(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithBatch(100, 2*time.Second),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, r.BatchSize())
+	assert.Equal(t, 2*time.Second, r.BatchMaxWait())
+
+	r, err = router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithBatch(10, 0),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, r.BatchMaxWait())
+}
+
+func TestNew_JetStream_NoBatch(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+	)
+	assert.NoError(t, err)
+	assert.Zero(t, r.BatchSize())
+	assert.Zero(t, r.BatchMaxWait())
+}
+
+func TestNew_JetStream_InvalidBatchSize(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithBatch(-1, time.Second),
+	)
+	assert.Nil(t, r)
+	assert.ErrorIs(t, err, loafernastx.ErrInvalidBatchSize)
+}
+
+func TestNew_WithName(t *testing.T) {
+	r, err := router.New(router.TypePubSub, "orders.*", router.WithName("orders"))
+	assert.NoError(t, err)
+	assert.Equal(t, "orders", r.Name())
+	assert.Equal(t, "orders", r.Label())
+
+	r, err = router.New(router.TypePubSub, "orders.*")
+	assert.NoError(t, err)
+	assert.Empty(t, r.Name())
+	assert.Equal(t, "orders.*", r.Label())
+}
+
+func TestNew_JetStream_ConsumerOptions(t *testing.T) {
+	r, err := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithMaxAckPending(-1),
+		router.WithInactiveThreshold(time.Hour),
+		router.WithFilterSubjects("orders.updated"),
+		router.WithFilterSubjects("orders.deleted"),
+		router.WithHeadersOnly(),
+		router.WithSampleFrequency(50),
+		router.WithBatch(10, time.Second),
+		router.WithMaxRequestBatch(10),
+		router.WithReplicas(3),
+		router.WithMemoryStorage(),
+		router.WithMetadata(map[string]string{"team": "orders"}),
+		router.WithMetadata(map[string]string{"tier": "gold"}),
+		router.WithDescription("orders lifecycle"),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, -1, r.MaxAckPending())
+	assert.Equal(t, time.Hour, r.InactiveThreshold())
+	assert.Equal(t, []string{"orders.created", "orders.updated", "orders.deleted"}, r.FilterSubjects())
+	assert.True(t, r.HeadersOnly())
+	assert.Equal(t, 50, r.SampleFrequency())
+	assert.Equal(t, 10, r.MaxRequestBatch())
+	assert.Equal(t, 3, r.Replicas())
+	assert.True(t, r.MemoryStorage())
+	assert.Equal(t, map[string]string{"team": "orders", "tier": "gold"}, r.Metadata())
+	assert.Equal(t, "orders lifecycle", r.Description())
+}
+
+func TestNew_JetStream_ConsumerDefaults(t *testing.T) {
+	r, err := router.New(router.TypeJetStream, "orders.created", router.WithStream("ORDERS"), router.WithDurable("d"))
+	assert.NoError(t, err)
+
+	assert.Zero(t, r.MaxAckPending())
+	assert.Zero(t, r.InactiveThreshold())
+	assert.Equal(t, []string{"orders.created"}, r.FilterSubjects())
+	assert.False(t, r.HeadersOnly())
+	assert.Zero(t, r.SampleFrequency())
+	assert.Zero(t, r.MaxRequestBatch())
+	assert.Zero(t, r.Replicas())
+	assert.False(t, r.MemoryStorage())
+	assert.Nil(t, r.Metadata())
+	assert.Empty(t, r.Description())
+}
+
+func TestNew_JetStream_InvalidConsumerOptions(t *testing.T) {
+	tests := []struct {
+		want error
+		name string
+		opts []router.Option
+	}{
+		{name: "max ack pending", opts: []router.Option{router.WithMaxAckPending(-2)}, want: loafernastx.ErrInvalidMaxAckPending},
+		{
+			name: "inactive threshold",
+			opts: []router.Option{router.WithInactiveThreshold(-time.Second)},
+			want: loafernastx.ErrInvalidInactiveThreshold,
+		},
+		{name: "empty filter subject", opts: []router.Option{router.WithFilterSubjects("")}, want: loafernastx.ErrInvalidFilterSubject},
+		{
+			name: "repeated filter subject",
+			opts: []router.Option{router.WithFilterSubjects("orders.created")},
+			want: loafernastx.ErrInvalidFilterSubject,
+		},
+		{name: "sample frequency", opts: []router.Option{router.WithSampleFrequency(101)}, want: loafernastx.ErrInvalidSampleFrequency},
+		{name: "negative max request batch", opts: []router.Option{router.WithMaxRequestBatch(-1)}, want: loafernastx.ErrInvalidMaxRequestBatch},
+		{
+			name: "max request batch below batch size",
+			opts: []router.Option{router.WithBatch(20, time.Second), router.WithMaxRequestBatch(10)},
+			want: loafernastx.ErrInvalidMaxRequestBatch,
+		},
+		{name: "replicas", opts: []router.Option{router.WithReplicas(6)}, want: loafernastx.ErrInvalidReplicas},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]router.Option{router.WithStream("ORDERS"), router.WithDurable("d")}, tt.opts...)
+
+			r, err := router.New(router.TypeJetStream, "orders.created", opts...)
+			assert.Nil(t, r)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
 }
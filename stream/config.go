@@ -0,0 +1,14 @@
+package stream
+
+import "time"
+
+type config struct {
+	subjects     []string
+	maxAge       time.Duration
+	duplicates   time.Duration
+	replicas     int
+	retention    Retention
+	storage      Storage
+	retentionSet bool
+	storageSet   bool
+}
@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+)
+
+// Definition declares the desired state of a JetStream stream. Settings that are
+// not configured are left to the server default when the stream is created and
+// are not changed when an existing stream is updated.
+type Definition struct {
+	cfg  *config
+	name string
+}
+
+// New creates a validated stream Definition.
+func New(name string, opts ...Option) (*Definition, error) {
+	cfg := &config{}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if name == "" {
+		return nil, loafernatsx.ErrMissingStreamName
+	}
+
+	if len(cfg.subjects) == 0 {
+		return nil, loafernatsx.ErrMissingStreamSubjects
+	}
+
+	return &Definition{name: name, cfg: cfg}, nil
+}
+
+// Name returns the stream name.
+func (d *Definition) Name() string {
+	return d.name
+}
+
+// Subjects returns the subjects bound to the stream.
+func (d *Definition) Subjects() []string {
+	return d.cfg.subjects
+}
+
+// Retention returns the configured retention policy.
+func (d *Definition) Retention() Retention {
+	return d.cfg.retention
+}
+
+// Storage returns the configured storage type.
+func (d *Definition) Storage() Storage {
+	return d.cfg.storage
+}
+
+// MaxAge returns the configured maximum message age, or zero when not configured.
+func (d *Definition) MaxAge() time.Duration {
+	return d.cfg.maxAge
+}
+
+// DuplicateWindow returns the configured deduplication window, or zero when not configured.
+func (d *Definition) DuplicateWindow() time.Duration {
+	return d.cfg.duplicates
+}
+
+// Replicas returns the configured number of replicas, or zero when not configured.
+func (d *Definition) Replicas() int {
+	return d.cfg.replicas
+}
+
+// apply writes the configured settings onto cfg and reports whether anything changed.
+// Subjects are merged: subjects already bound to cfg, or covered by one of its
+// wildcards, are kept as they are.
+func (d *Definition) apply(cfg *jetstream.StreamConfig) bool {
+	changed := false
+
+	for _, s := range d.cfg.subjects {
+		if !covered(cfg.Subjects, s) {
+			cfg.Subjects = append(cfg.Subjects, s)
+			changed = true
+		}
+	}
+
+	if d.cfg.retentionSet && cfg.Retention != jetstream.RetentionPolicy(d.cfg.retention) {
+		cfg.Retention = jetstream.RetentionPolicy(d.cfg.retention)
+		changed = true
+	}
+
+	if d.cfg.storageSet && cfg.Storage != jetstream.StorageType(d.cfg.storage) {
+		cfg.Storage = jetstream.StorageType(d.cfg.storage)
+		changed = true
+	}
+
+	if d.cfg.maxAge > 0 && cfg.MaxAge != d.cfg.maxAge {
+		cfg.MaxAge = d.cfg.maxAge
+		changed = true
+	}
+
+	if d.cfg.duplicates > 0 && cfg.Duplicates != d.cfg.duplicates {
+		cfg.Duplicates = d.cfg.duplicates
+		changed = true
+	}
+
+	if d.cfg.replicas > 0 && cfg.Replicas != d.cfg.replicas {
+		cfg.Replicas = d.cfg.replicas
+		changed = true
+	}
+
+	return changed
+}
+
+// covered reports whether subject is already captured by one of the patterns.
+func covered(patterns []string, subject string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		return subjectMatches(p, subject)
+	})
+}
@@ -0,0 +1,56 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	loafernatsx "github.com/silviolleite/loafer-natsx"
+	"github.com/silviolleite/loafer-natsx/stream"
+)
+
+func TestNew_MissingName(t *testing.T) {
+	d, err := stream.New("", stream.WithSubjects("orders.>"))
+	assert.Nil(t, d)
+	assert.ErrorIs(t, err, loafernatsx.ErrMissingStreamName)
+}
+
+func TestNew_MissingSubjects(t *testing.T) {
+	d, err := stream.New("ORDERS")
+	assert.Nil(t, d)
+	assert.ErrorIs(t, err, loafernatsx.ErrMissingStreamSubjects)
+}
+
+func TestNew_Defaults(t *testing.T) {
+	d, err := stream.New("ORDERS", stream.WithSubjects("orders.created"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ORDERS", d.Name())
+	assert.Equal(t, []string{"orders.created"}, d.Subjects())
+	assert.Equal(t, stream.LimitsRetention, d.Retention())
+	assert.Equal(t, stream.FileStorage, d.Storage())
+	assert.Zero(t, d.MaxAge())
+	assert.Zero(t, d.DuplicateWindow())
+	assert.Zero(t, d.Replicas())
+}
+
+func TestNew_CustomValues(t *testing.T) {
+	d, err := stream.New(
+		"ORDERS",
+		stream.WithSubjects("orders.created", "orders.cancelled"),
+		stream.WithSubjects("orders.failed"),
+		stream.WithRetention(stream.WorkQueueRetention),
+		stream.WithStorage(stream.MemoryStorage),
+		stream.WithMaxAge(7*24*time.Hour),
+		stream.WithDuplicateWindow(time.Minute),
+		stream.WithReplicas(3),
+		stream.WithReplicas(0),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orders.created", "orders.cancelled", "orders.failed"}, d.Subjects())
+	assert.Equal(t, stream.WorkQueueRetention, d.Retention())
+	assert.Equal(t, stream.MemoryStorage, d.Storage())
+	assert.Equal(t, 7*24*time.Hour, d.MaxAge())
+	assert.Equal(t, time.Minute, d.DuplicateWindow())
+	assert.Equal(t, 3, d.Replicas())
+}
@@ -0,0 +1,117 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/silviolleite/loafer-natsx/router"
+)
+
+// dlqStreamSuffix is appended to the route stream name to name the DLQ stream
+// created for a route.
+const dlqStreamSuffix = "_DLQ"
+
+// Ensure creates the streams that do not exist and updates the existing ones so
+// they match their definitions. It is idempotent: streams already in the desired
+// state are not updated. Subjects already bound to an existing stream are kept, so
+// several services can declare the subjects they depend on.
+func Ensure(ctx context.Context, js jetstream.JetStream, defs ...*Definition) error {
+	for _, d := range defs {
+		if err := ensure(ctx, js, d); err != nil {
+			return fmt.Errorf("ensure stream %s: %w", d.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func ensure(ctx context.Context, js jetstream.JetStream, d *Definition) error {
+	s, err := js.Stream(ctx, d.Name())
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		cfg := jetstream.StreamConfig{Name: d.Name()}
+		d.apply(&cfg)
+
+		_, err = js.CreateStream(ctx, cfg)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	cfg := s.CachedInfo().Config
+	if !d.apply(&cfg) {
+		return nil
+	}
+
+	_, err = js.UpdateStream(ctx, cfg)
+	return err
+}
+
+// ForRoutes derives stream definitions from JetStream routes: the route stream
+// bound to the route filter subjects and, for routes with the DLQ enabled, a
+// "<stream>_DLQ" stream bound to their DLQ subjects. Other route types are ignored.
+func ForRoutes(routes ...*router.Route) []*Definition {
+	defs := make([]*Definition, 0, len(routes))
+
+	for _, r := range routes {
+		if r == nil || r.Type() != router.TypeJetStream {
+			continue
+		}
+
+		subjects := r.FilterSubjects()
+
+		defs = append(defs, &Definition{
+			name: r.Stream(),
+			cfg:  &config{subjects: subjects},
+		})
+
+		if r.DLQEnabled() {
+			dlqSubjects := make([]string, 0, len(subjects))
+			for _, s := range subjects {
+				if dlq := r.DLQSubject(s); !slices.Contains(dlqSubjects, dlq) {
+					dlqSubjects = append(dlqSubjects, dlq)
+				}
+			}
+
+			defs = append(defs, &Definition{
+				name: r.Stream() + dlqStreamSuffix,
+				cfg:  &config{subjects: dlqSubjects},
+			})
+		}
+	}
+
+	return defs
+}
+
+// EnsureRoutes ensures the streams derived from routes with ForRoutes. A DLQ stream
+// is not created when another stream already captures the route DLQ subject.
+func EnsureRoutes(ctx context.Context, js jetstream.JetStream, routes ...*router.Route) error {
+	for _, d := range ForRoutes(routes...) {
+		if captured(ctx, js, d) {
+			continue
+		}
+
+		if err := Ensure(ctx, js, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// captured reports whether the subjects of d are already bound to a stream other than d.
+func captured(ctx context.Context, js jetstream.JetStream, d *Definition) bool {
+	for _, s := range d.Subjects() {
+		name, err := js.StreamNameBySubject(ctx, s)
+		if err != nil || name == d.Name() {
+			return false
+		}
+	}
+
+	return true
+}
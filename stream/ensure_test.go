@@ -0,0 +1,211 @@
+package stream_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silviolleite/loafer-natsx/router"
+	"github.com/silviolleite/loafer-natsx/stream"
+)
+
+func runServer() (*server.Server, string) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	s := natstest.RunServer(&opts)
+	return s, s.ClientURL()
+}
+
+func setup(t *testing.T, streams ...string) jetstream.JetStream {
+	t.Helper()
+
+	s, url := runServer()
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	for _, name := range streams {
+		_ = js.DeleteStream(context.Background(), name)
+	}
+
+	return js
+}
+
+func streamConfig(t *testing.T, js jetstream.JetStream, name string) jetstream.StreamConfig {
+	t.Helper()
+
+	s, err := js.Stream(context.Background(), name)
+	require.NoError(t, err)
+
+	return s.CachedInfo().Config
+}
+
+func TestEnsure_CreatesStream(t *testing.T) {
+	js := setup(t, "ENSURE_CREATE")
+
+	d, _ := stream.New(
+		"ENSURE_CREATE",
+		stream.WithSubjects("ensure.create.>"),
+		stream.WithRetention(stream.WorkQueueRetention),
+		stream.WithStorage(stream.MemoryStorage),
+		stream.WithMaxAge(time.Hour),
+		stream.WithDuplicateWindow(time.Minute),
+		stream.WithReplicas(1),
+	)
+
+	require.NoError(t, stream.Ensure(context.Background(), js, d))
+
+	cfg := streamConfig(t, js, "ENSURE_CREATE")
+	assert.Equal(t, []string{"ensure.create.>"}, cfg.Subjects)
+	assert.Equal(t, jetstream.WorkQueuePolicy, cfg.Retention)
+	assert.Equal(t, jetstream.MemoryStorage, cfg.Storage)
+	assert.Equal(t, time.Hour, cfg.MaxAge)
+	assert.Equal(t, time.Minute, cfg.Duplicates)
+	assert.Equal(t, 1, cfg.Replicas)
+}
+
+func TestEnsure_IsIdempotentAndUpdates(t *testing.T) {
+	js := setup(t, "ENSURE_UPDATE")
+
+	_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "ENSURE_UPDATE",
+		Subjects: []string{"ensure.update.created", "ensure.update.legacy.*"},
+		MaxAge:   time.Hour,
+		MaxMsgs:  1000,
+	})
+	require.NoError(t, err)
+
+	d, _ := stream.New(
+		"ENSURE_UPDATE",
+		stream.WithSubjects("ensure.update.created", "ensure.update.legacy.x", "ensure.update.cancelled"),
+		stream.WithMaxAge(2*time.Hour),
+	)
+
+	require.NoError(t, stream.Ensure(context.Background(), js, d))
+	require.NoError(t, stream.Ensure(context.Background(), js, d))
+
+	cfg := streamConfig(t, js, "ENSURE_UPDATE")
+	assert.Equal(t, []string{"ensure.update.created", "ensure.update.legacy.*", "ensure.update.cancelled"}, cfg.Subjects)
+	assert.Equal(t, 2*time.Hour, cfg.MaxAge)
+	assert.Equal(t, int64(1000), cfg.MaxMsgs, "settings outside the definition are kept")
+}
+
+func TestEnsure_ReportsStreamName(t *testing.T) {
+	js := setup(t, "ENSURE_STORAGE")
+
+	_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "ENSURE_STORAGE",
+		Subjects: []string{"ensure.storage"},
+	})
+	require.NoError(t, err)
+
+	d, _ := stream.New("ENSURE_STORAGE", stream.WithSubjects("ensure.storage"), stream.WithStorage(stream.MemoryStorage))
+
+	err = stream.Ensure(context.Background(), js, d)
+	assert.ErrorContains(t, err, "ensure stream ENSURE_STORAGE")
+}
+
+func TestForRoutes(t *testing.T) {
+	js, _ := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithEnableDLQ(),
+	)
+	noDLQ, _ := router.New(router.TypeJetStream, "payments.*", router.WithStream("PAYMENTS"), router.WithDurable("d"))
+	pubsub, _ := router.New(router.TypePubSub, "events")
+
+	defs := stream.ForRoutes(js, noDLQ, pubsub, nil)
+	require.Len(t, defs, 3)
+
+	assert.Equal(t, "ORDERS", defs[0].Name())
+	assert.Equal(t, []string{"orders.created"}, defs[0].Subjects())
+	assert.Equal(t, "ORDERS_DLQ", defs[1].Name())
+	assert.Equal(t, []string{"dlq.orders.created"}, defs[1].Subjects())
+	assert.Equal(t, "PAYMENTS", defs[2].Name())
+	assert.Equal(t, []string{"payments.*"}, defs[2].Subjects())
+}
+
+func TestForRoutes_FilterSubjects(t *testing.T) {
+	r, _ := router.New(
+		router.TypeJetStream,
+		"orders.created",
+		router.WithStream("ORDERS"),
+		router.WithDurable("d"),
+		router.WithFilterSubjects("orders.updated"),
+		router.WithEnableDLQ(),
+	)
+	fixedDLQ, _ := router.New(
+		router.TypeJetStream,
+		"payments.created",
+		router.WithStream("PAYMENTS"),
+		router.WithDurable("d"),
+		router.WithFilterSubjects("payments.updated"),
+		router.WithDLQSubject("dlq.payments"),
+	)
+
+	defs := stream.ForRoutes(r, fixedDLQ)
+	require.Len(t, defs, 4)
+
+	assert.Equal(t, []string{"orders.created", "orders.updated"}, defs[0].Subjects())
+	assert.Equal(t, []string{"dlq.orders.created", "dlq.orders.updated"}, defs[1].Subjects())
+	assert.Equal(t, []string{"payments.created", "payments.updated"}, defs[2].Subjects())
+	assert.Equal(t, []string{"dlq.payments"}, defs[3].Subjects())
+}
+
+func TestEnsureRoutes(t *testing.T) {
+	js := setup(t, "ROUTES", "ROUTES_DLQ", "SHARED_DLQ", "SHARED", "SHARED_DLQ_ALL")
+
+	created, _ := router.New(
+		router.TypeJetStream,
+		"routes.created",
+		router.WithStream("ROUTES"),
+		router.WithDurable("d"),
+		router.WithEnableDLQ(),
+	)
+	cancelled, _ := router.New(
+		router.TypeJetStream,
+		"routes.cancelled",
+		router.WithStream("ROUTES"),
+		router.WithDurable("d2"),
+	)
+
+	require.NoError(t, stream.EnsureRoutes(context.Background(), js, created, cancelled))
+
+	assert.Equal(t, []string{"routes.created", "routes.cancelled"}, streamConfig(t, js, "ROUTES").Subjects)
+	assert.Equal(t, []string{"dlq.routes.created"}, streamConfig(t, js, "ROUTES_DLQ").Subjects)
+
+	// A DLQ subject captured by an existing stream does not get a dedicated stream.
+	_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "SHARED_DLQ_ALL",
+		Subjects: []string{"dlq.shared.>"},
+	})
+	require.NoError(t, err)
+
+	shared, _ := router.New(
+		router.TypeJetStream,
+		"shared.created",
+		router.WithStream("SHARED"),
+		router.WithDurable("d"),
+		router.WithEnableDLQ(),
+	)
+
+	require.NoError(t, stream.EnsureRoutes(context.Background(), js, shared))
+
+	_, err = js.Stream(context.Background(), "SHARED_DLQ")
+	assert.ErrorIs(t, err, jetstream.ErrStreamNotFound)
+}
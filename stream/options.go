@@ -0,0 +1,54 @@
+package stream
+
+import "time"
+
+// Option configures a stream Definition during creation.
+type Option func(*config)
+
+// WithSubjects sets the subjects bound to the stream. Wildcards are allowed.
+func WithSubjects(subjects ...string) Option {
+	return func(c *config) {
+		c.subjects = append(c.subjects, subjects...)
+	}
+}
+
+// WithRetention sets the stream retention policy.
+func WithRetention(r Retention) Option {
+	return func(c *config) {
+		c.retention = r
+		c.retentionSet = true
+	}
+}
+
+// WithStorage sets the stream storage type. JetStream does not allow changing the
+// storage of an existing stream.
+func WithStorage(s Storage) Option {
+	return func(c *config) {
+		c.storage = s
+		c.storageSet = true
+	}
+}
+
+// WithMaxAge sets the maximum age of messages in the stream.
+func WithMaxAge(d time.Duration) Option {
+	return func(c *config) {
+		c.maxAge = d
+	}
+}
+
+// WithDuplicateWindow sets the window in which messages with the same Nats-Msg-Id are discarded.
+func WithDuplicateWindow(d time.Duration) Option {
+	return func(c *config) {
+		c.duplicates = d
+	}
+}
+
+// WithReplicas sets the number of stream replicas in a clustered deployment.
+// Values lower than 1 are ignored.
+func WithReplicas(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.replicas = n
+		}
+	}
+}
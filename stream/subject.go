@@ -0,0 +1,32 @@
+package stream
+
+import "strings"
+
+// subjectMatches reports whether every subject matched by subject is also matched
+// by pattern, following the NATS wildcard rules ("*" matches one token and ">"
+// matches one or more trailing tokens).
+func subjectMatches(pattern, subject string) bool {
+	pt := strings.Split(pattern, ".")
+	st := strings.Split(subject, ".")
+
+	for i, p := range pt {
+		if p == ">" {
+			return len(st) > i
+		}
+
+		if i >= len(st) {
+			return false
+		}
+
+		switch {
+		case p == "*":
+			if st[i] == ">" {
+				return false
+			}
+		case p != st[i]:
+			return false
+		}
+	}
+
+	return len(pt) == len(st)
+}
@@ -0,0 +1,27 @@
+package stream
+
+// Retention defines how messages are retained in a stream.
+type Retention int
+
+const (
+	// LimitsRetention keeps messages until the stream limits (max age, size or count) are reached.
+	// This is the JetStream default.
+	LimitsRetention Retention = iota
+
+	// InterestRetention keeps messages while consumers are interested in them.
+	InterestRetention
+
+	// WorkQueueRetention removes messages once they are acknowledged by a consumer.
+	WorkQueueRetention
+)
+
+// Storage defines where a stream stores its messages.
+type Storage int
+
+const (
+	// FileStorage stores messages on disk. This is the JetStream default.
+	FileStorage Storage = iota
+
+	// MemoryStorage keeps messages in memory only.
+	MemoryStorage
+)
@@ -11,6 +11,17 @@ import (
 // and returns a response of type R.
 type HandlerFunc[T any, R any] func(ctx context.Context, msg T) (R, error)
 
+// Message carries a decoded payload of type T together with the underlying
+// consumer.Message, so headers, subject and JetStream metadata stay reachable.
+type Message[T any] struct {
+	consumer.Message
+	Body T
+}
+
+// MessageHandlerFunc is a type-safe handler that receives the decoded payload
+// along with the message headers, subject and metadata.
+type MessageHandlerFunc[T any, R any] func(ctx context.Context, msg Message[T]) (R, error)
+
 // WrapHandler adapts a typed HandlerFunc into a consumer.HandlerFunc by
 // decoding the raw bytes with the provided codec before invoking fn.
 func WrapHandler[T any, R any](codec Codec[T], fn HandlerFunc[T, R]) consumer.HandlerFunc {
@@ -23,3 +34,16 @@ func WrapHandler[T any, R any](codec Codec[T], fn HandlerFunc[T, R]) consumer.Ha
 		return fn(ctx, msg)
 	}
 }
+
+// WrapMessageHandler adapts a typed MessageHandlerFunc into a consumer.MessageHandlerFunc
+// by decoding the message payload with the provided codec before invoking fn.
+func WrapMessageHandler[T any, R any](codec Codec[T], fn MessageHandlerFunc[T, R]) consumer.MessageHandlerFunc {
+	return func(ctx context.Context, msg consumer.Message) (any, error) {
+		body, err := codec.Decode(msg.Data())
+		if err != nil {
+			return nil, fmt.Errorf("typed: decode: %w", err)
+		}
+
+		return fn(ctx, Message[T]{Message: msg, Body: body})
+	}
+}
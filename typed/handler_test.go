@@ -4,8 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/silviolleite/loafer-natsx/consumer"
 	"github.com/silviolleite/loafer-natsx/typed"
 )
 
@@ -42,3 +44,39 @@ func TestWrapHandler_DecodeError(t *testing.T) {
 	assert.Nil(t, result)
 	assert.False(t, called)
 }
+
+func TestWrapMessageHandler_Success(t *testing.T) {
+	codec := typed.JSONCodec[order]{}
+
+	handler := typed.WrapMessageHandler(codec, func(_ context.Context, msg typed.Message[order]) (string, error) {
+		assert.Equal(t, "abc", msg.Body.ID)
+		assert.Equal(t, "orders.created", msg.Subject())
+		assert.Equal(t, "cid-1", msg.Headers().Get("X-Correlation-ID"))
+		return "ok", nil
+	})
+
+	data, _ := codec.Encode(order{ID: "abc", Amount: 10})
+	raw := &nats.Msg{Subject: "orders.created", Data: data, Header: nats.Header{}}
+	raw.Header.Set("X-Correlation-ID", "cid-1")
+
+	result, err := handler(context.Background(), consumer.NewMessage(raw))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+func TestWrapMessageHandler_DecodeError(t *testing.T) {
+	codec := typed.JSONCodec[order]{}
+	called := false
+
+	handler := typed.WrapMessageHandler(codec, func(_ context.Context, _ typed.Message[order]) (any, error) {
+		called = true
+		return nil, nil
+	})
+
+	result, err := handler(context.Background(), consumer.NewMessage(&nats.Msg{Data: []byte("bad json")}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "decode")
+	assert.Nil(t, result)
+	assert.False(t, called)
+}